@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/data"
+)
+
+// runBackup writes a consistent copy of the database to a new file. It is
+// safe to run while the server is running.
+func runBackup(args []string) error {
+	flags := config.NewFlagSet("backup")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: mainframe backup <file>")
+	}
+
+	path := flags.Arg(0)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	if err := data.Backup(container.DB, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("backed up the database to %s\n", path)
+	return nil
+}
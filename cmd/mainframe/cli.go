@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/th3oth3rjak3/mainframe/internal/api"
+	"github.com/th3oth3rjak3/mainframe/internal/data"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"golang.org/x/term"
+)
+
+// cliClient identifies the command line in the audit log.
+var cliClient = domain.ClientInfo{UserAgent: "mainframe-cli"}
+
+// openContainer loads the configuration, connects to the database and
+// builds the service container used by a command, the same as when the
+// server starts. The flags must come from config.NewFlagSet and already be
+// parsed. The caller closes container.DB when done.
+func openContainer(flags *flag.FlagSet) (*api.ServiceContainer, error) {
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	logger, err := cfg.Log.NewLogger(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := data.InitDB(cfg.Database, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	container, err := api.NewServiceContainer(db, cfg, logger)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize service container: %w", err)
+	}
+
+	return container, nil
+}
+
+// runSubcommand runs the subcommand named by the first argument.
+func runSubcommand(usage string, args []string, subcommands map[string]func(args []string) error) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return errors.New(usage)
+	}
+
+	return run(args[1:])
+}
+
+// subcommandUsage builds a usage message listing the subcommands.
+func subcommandUsage(name string, subcommands map[string]func(args []string) error) string {
+	names := make([]string, 0, len(subcommands))
+	for subcommand := range subcommands {
+		names = append(names, subcommand)
+	}
+	sort.Strings(names)
+
+	return fmt.Sprintf("usage: mainframe %s %s", name, strings.Join(names, "|"))
+}
+
+// findUser looks up a user by username.
+func findUser(ctx context.Context, container *api.ServiceContainer, username string) (*domain.User, error) {
+	user, err := container.UserRepository.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %q: %w", username, err)
+	}
+
+	return user, nil
+}
+
+func promptLine(reader *bufio.Reader, prompt string) (string, error) {
+	fmt.Print(prompt)
+
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// promptPassword reads the password twice without echoing it. When input
+// isn't a terminal, such as in a script, a single line is read instead.
+func promptPassword(reader *bufio.Reader) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return promptLine(reader, "Password: ")
+	}
+
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	fmt.Print("Confirm password: ")
+	confirmation, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	if string(password) != string(confirmation) {
+		return "", errors.New("the passwords do not match")
+	}
+
+	return string(password), nil
+}
+
+// promptUserDetails asks for any of the details that weren't given as flags.
+func promptUserDetails(reader *bufio.Reader, request *domain.UserCreate) error {
+	fields := []struct {
+		prompt string
+		value  *string
+	}{
+		{"Username", &request.Username},
+		{"Email", &request.Email},
+		{"First name", &request.FirstName},
+		{"Last name", &request.LastName},
+	}
+
+	for _, field := range fields {
+		if *field.value != "" {
+			continue
+		}
+
+		value, err := promptLine(reader, field.prompt+": ")
+		if err != nil {
+			return err
+		}
+
+		*field.value = value
+	}
+
+	return nil
+}
+
+// userDetailFlags adds the flags shared by the commands that create users.
+func userDetailFlags(flags *flag.FlagSet, request *domain.UserCreate) {
+	flags.StringVar(&request.Username, "username", "", "username")
+	flags.StringVar(&request.Email, "email", "", "email address")
+	flags.StringVar(&request.FirstName, "first-name", "", "first name")
+	flags.StringVar(&request.LastName, "last-name", "", "last name")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+)
+
+// loadConfig loads and validates the configuration for a command. The flags
+// must come from config.NewFlagSet and already be parsed.
+func loadConfig(flags *flag.FlagSet) (*config.Config, error) {
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// runConfig prints the configuration the other commands would use, with
+// secrets redacted, and reports any problems with it.
+func runConfig(args []string) error {
+	flags := config.NewFlagSet("config")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return err
+	}
+
+	dump, err := cfg.Dump()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(dump)
+	return cfg.Validate()
+}
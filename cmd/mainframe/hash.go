@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+// runHash prints the argon2id hash of a password, read without echoing it.
+func runHash(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: mainframe hash")
+	}
+
+	password, err := promptPassword(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return err
+	}
+
+	if password == "" {
+		return errors.New("the password can't be empty")
+	}
+
+	hash, err := domain.NewPasswordHasher().HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	fmt.Println(hash)
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/data"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+)
+
+var keyCommands = map[string]func(args []string) error{
+	"generate":  runKeyGenerate,
+	"reencrypt": runKeyReencrypt,
+	"retire":    runKeyRetire,
+}
+
+// runKey handles the key command, which manages the server keys used to
+// sign session tokens and encrypt MFA secrets. generate works on files only
+// so it can be used before the server has ever started.
+func runKey(args []string) error {
+	return runSubcommand(subcommandUsage("key", keyCommands), args, keyCommands)
+}
+
+// runKeyGenerate prints a new server key. With -keyring the key is added
+// to the keyring file and made the primary key instead. The file is
+// created when it doesn't exist, and the configured server key is carried
+// over so existing sessions stay valid.
+func runKeyGenerate(args []string) error {
+	flags := config.NewFlagSet("key generate")
+	keyringPath := flags.String("keyring", "", "path to the keyring file to add the new key to")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	encodedKey, err := generateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate server key: %w", err)
+	}
+
+	if *keyringPath == "" {
+		fmt.Println(encodedKey)
+		return nil
+	}
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return err
+	}
+
+	keyID, err := addKey(*keyringPath, cfg.Keys.ServerKey, encodedKey)
+	if err != nil {
+		return fmt.Errorf("failed to add key to keyring: %w", err)
+	}
+
+	fmt.Printf("added primary key %s to %s\n", keyID, *keyringPath)
+	return nil
+}
+
+// runKeyReencrypt encrypts the stored MFA secrets again with the primary
+// key. The server also does this when it starts, so this is only needed to
+// retire a key without restarting.
+func runKeyReencrypt(args []string) error {
+	flags := config.NewFlagSet("key reencrypt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	rewritten, err := container.MFAService.ReencryptSecrets(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("re-encrypted %d mfa secrets with key %s\n", rewritten, container.Keyring.PrimaryID())
+	return nil
+}
+
+// runKeyRetire removes an old key from the keyring file once nothing
+// stored in the database depends on it any more.
+func runKeyRetire(args []string) error {
+	flags := config.NewFlagSet("key retire")
+	keyringPath := flags.String("keyring", "", "path to the keyring file to remove the key from")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *keyringPath == "" || flags.NArg() != 1 {
+		return errors.New("usage: mainframe key retire -keyring <file> <id>")
+	}
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return err
+	}
+
+	db, err := data.Open(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	keyID := flags.Arg(0)
+	err = retireKey(context.Background(), *keyringPath, keyID, repository.NewKeyRepository(db))
+	if err != nil {
+		return fmt.Errorf("failed to retire key: %w", err)
+	}
+
+	fmt.Printf("retired key %s\n", keyID)
+	return nil
+}
+
+func generateKey() (string, error) {
+	bytes, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// addKey adds the key to the keyring file as the new primary key and returns
+// its id. A new file starts with serverKey, when given, as its first key.
+func addKey(path string, serverKey string, key string) (string, error) {
+	file, err := crypto.ReadKeyringFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		file = &crypto.KeyringFile{Keys: []crypto.KeyringEntry{}}
+
+		if serverKey != "" {
+			file.Keys = append(file.Keys, crypto.KeyringEntry{
+				ID:        crypto.DefaultKeyID,
+				Key:       serverKey,
+				CreatedAt: time.Now().UTC(),
+			})
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	keyID := now.Format("20060102150405")
+
+	file.Keys = append(file.Keys, crypto.KeyringEntry{ID: keyID, Key: key, CreatedAt: now})
+	file.Primary = keyID
+
+	if _, err := file.Keyring(); err != nil {
+		return "", err
+	}
+
+	return keyID, crypto.WriteKeyringFile(path, file)
+}
+
+// retireKey removes a key from the keyring file. The primary key can't be
+// retired, and neither can a key that stored values still depend on.
+func retireKey(ctx context.Context, path string, keyID string, keyRepository repository.KeyRepository) error {
+	file, err := crypto.ReadKeyringFile(path)
+	if err != nil {
+		return err
+	}
+
+	if file.Primary == keyID {
+		return errors.New("the primary key can't be retired, add a new key first")
+	}
+
+	index := slices.IndexFunc(file.Keys, func(entry crypto.KeyringEntry) bool {
+		return entry.ID == keyID
+	})
+
+	if index < 0 {
+		return errors.New("key not found in keyring")
+	}
+
+	references, err := keyRepository.CountReferences(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	if references.Total() > 0 {
+		return fmt.Errorf(
+			"key %s is still in use by %d mfa secrets, %d sessions, %d access tokens, "+
+				"%d mfa challenges and %d password reset tokens; "+
+				"run `mainframe key reencrypt` and wait for the sessions and tokens to expire or revoke them",
+			keyID,
+			references.MFASecrets,
+			references.Sessions,
+			references.AccessTokens,
+			references.MFAChallenges,
+			references.PasswordResetTokens,
+		)
+	}
+
+	file.Keys = slices.Delete(file.Keys, index, index+1)
+
+	return crypto.WriteKeyringFile(path, file)
+}
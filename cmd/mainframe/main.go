@@ -0,0 +1,92 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+)
+
+//go:embed web/*
+var webAssets embed.FS
+
+// command is a subcommand of the mainframe CLI.
+type command struct {
+	synopsis    string
+	description string
+	run         func(args []string) error
+}
+
+// commands lists every subcommand by name. Commands with their own
+// subcommands, such as user, dispatch again with runSubcommand.
+var commands = map[string]command{
+	"serve":   {"serve", "run the HTTP server", runServe},
+	"migrate": {"migrate [flags] status|up|down", "manage the database schema", runMigrate},
+	"setup":   {"setup", "create the first administrator", runSetup},
+	"user":    {"user create|list|disable|reset-password", "manage users", runUser},
+	"role":    {"role grant|revoke <username> <role>", "change the roles a user has", runRole},
+	"session": {"session purge [-user <username>]", "end expired sessions, or every session of one user", runSession},
+	"key":     {"key generate|reencrypt|retire [-keyring <file>]", "create, apply or retire server keys", runKey},
+	"hash":    {"hash", "hash a password for storage", runHash},
+	"backup":  {"backup <file>", "copy the database to a file", runBackup},
+	"config":  {"config", "print the configuration with secrets redacted", runConfig},
+}
+
+// commandOrder is the order commands are listed in the usage message.
+var commandOrder = []string{"serve", "migrate", "setup", "user", "role", "session", "key", "hash", "backup", "config"}
+
+// @title           Mainframe API
+// @version         1.0
+// @description     Centralized Personal Productivity Application
+// @host            localhost:8080
+// @BasePath        /
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: .env file not found, relying on environment variables")
+	}
+
+	// With no command, or only flags, the server is started so existing
+	// scripts that run the binary directly keep working.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		printUsage()
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: mainframe <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	writer := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+	for _, name := range commandOrder {
+		fmt.Fprintf(writer, "  %s\t%s\n", commands[name].synopsis, commands[name].description)
+	}
+	writer.Flush()
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands that read the configuration accept -config, -env, -addr, -db")
+	fmt.Fprintln(os.Stderr, "and -migrate, which override the configuration file and environment.")
+	fmt.Fprintln(os.Stderr, "Run `mainframe config` to see the settings in effect.")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"text/tabwriter"
+
+	"github.com/pressly/goose/v3"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/data"
+)
+
+const migrateUsage = "usage: mainframe migrate [flags] status|up|down"
+
+// runMigrate handles the migrate subcommand. status lists every embedded
+// migration and whether it has been applied, up applies all pending
+// migrations and down rolls back the most recent one.
+func runMigrate(args []string) error {
+	flags := config.NewFlagSet("migrate")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return err
+	}
+
+	db, err := data.Open(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	provider, err := data.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch flags.Arg(0) {
+	case "status":
+		return printMigrationStatus(ctx, provider)
+	case "up":
+		if err := data.CheckSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+
+		results, err := provider.Up(ctx)
+		for _, result := range results {
+			fmt.Printf("applied %s\n", path.Base(result.Source.Path))
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return nil
+	case "down":
+		if err := data.CheckSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+
+		result, err := provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			fmt.Println("no migrations to roll back")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("rolled back %s\n", path.Base(result.Source.Path))
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func printMigrationStatus(ctx context.Context, provider *goose.Provider) error {
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "MIGRATION\tAPPLIED AT")
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\n", path.Base(status.Source.Path), appliedAt)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/th3oth3rjak3/mainframe/internal/api"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+var roleCommands = map[string]func(args []string) error{
+	"grant":  runRoleGrant,
+	"revoke": runRoleRevoke,
+}
+
+// runRole handles the role command.
+func runRole(args []string) error {
+	return runSubcommand(subcommandUsage("role", roleCommands)+" <username> <role>", args, roleCommands)
+}
+
+// runRoleGrant gives a user a role, named as it appears in the API.
+func runRoleGrant(args []string) error {
+	return changeRole("grant", args, func(ctx context.Context, container *api.ServiceContainer, user *domain.User, role *domain.Role) error {
+		err := container.UserService.AddRole(ctx, domain.NewSystemActor(), user.ID, role.ID, cliClient)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("granted %s to %s\n", role.Name, user.Username)
+		return nil
+	})
+}
+
+// runRoleRevoke takes a role away from a user. The last Administrator
+// can't lose the role, the same as through the API.
+func runRoleRevoke(args []string) error {
+	return changeRole("revoke", args, func(ctx context.Context, container *api.ServiceContainer, user *domain.User, role *domain.Role) error {
+		err := container.UserService.RemoveRole(ctx, domain.NewSystemActor(), user.ID, role.ID, cliClient)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("revoked %s from %s\n", role.Name, user.Username)
+		return nil
+	})
+}
+
+// changeRole looks up the user and role named in the arguments and passes
+// them to change.
+func changeRole(
+	name string,
+	args []string,
+	change func(ctx context.Context, container *api.ServiceContainer, user *domain.User, role *domain.Role) error,
+) error {
+	flags := config.NewFlagSet("role " + name)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: mainframe role %s <username> <role>", name)
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	user, err := findUser(ctx, container, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	role, err := container.RoleRepository.GetByName(ctx, flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("failed to find role %q: %w", flags.Arg(1), err)
+	}
+
+	return change(ctx, container, user, role)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/th3oth3rjak3/mainframe/internal/api"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/data"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/tracing"
+)
+
+// runServe starts the HTTP server and the background jobs and blocks until
+// the process is told to stop.
+func runServe(args []string) error {
+	flags := config.NewFlagSet("serve")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	logger, err := cfg.Log.NewLogger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	// 🔑 ONE root context tied to OS signals
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	defer func() {
+		// Flush any spans still queued for export before exiting.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("failed to shut down tracing", "error", err)
+		}
+	}()
+
+	db, err := data.InitDB(cfg.Database, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	container, err := api.NewServiceContainer(db, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize service container: %w", err)
+	}
+
+	// Move MFA secrets onto the primary key so older keys can be retired.
+	reencrypted, err := container.MFAService.ReencryptSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt mfa secrets: %w", err)
+	}
+
+	if reencrypted > 0 {
+		logger.Info("mfa secrets re-encrypted with the primary key",
+			"count", reencrypted,
+			"key_id", container.Keyring.PrimaryID(),
+		)
+	}
+
+	setupToken, err := container.SetupService.IssueToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check whether setup is required: %w", err)
+	}
+
+	if setupToken != "" {
+		logger.Warn(
+			"no users exist yet; create the first administrator with the setup token, or run `mainframe setup`",
+			"url", cfg.Server.BaseURL+"/api/setup",
+			"setup_token", setupToken,
+		)
+	}
+
+	server := api.NewServer(container, cfg, webAssets)
+
+	userRetentionService := services.NewUserRetentionService(container.UserRepository, container.AuditService, cfg.Users.RetentionPeriod, logger)
+
+	go func() {
+		if err := server.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("server stopped unexpectedly", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go services.RunSessionCleanupJob(ctx, container.SessionCleanupService, logger)
+	go services.RunUserRetentionJob(ctx, userRetentionService, logger)
+
+	// ⛔ Block until shutdown signal
+	<-ctx.Done()
+	logger.Info("shutdown signal received")
+
+	// Graceful server shutdown
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctxShutdown)
+
+	// Let reset emails for requests that were already answered go out.
+	if waitErr := container.PasswordResetService.Wait(ctxShutdown); waitErr != nil {
+		logger.Warn("password reset emails were still being sent at shutdown", "error", waitErr)
+	}
+
+	return err
+}
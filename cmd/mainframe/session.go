@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+var sessionCommands = map[string]func(args []string) error{
+	"purge": runSessionPurge,
+}
+
+// runSession handles the session command.
+func runSession(args []string) error {
+	return runSubcommand(subcommandUsage("session", sessionCommands), args, sessionCommands)
+}
+
+// runSessionPurge deletes expired sessions and other expired credentials,
+// the same as the background cleanup job. With -user it ends every session
+// of that user instead.
+func runSessionPurge(args []string) error {
+	flags := config.NewFlagSet("session purge")
+	username := flags.String("user", "", "end every session of this user")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	if *username == "" {
+		return container.SessionCleanupService.DeleteExpired(ctx)
+	}
+
+	user, err := findUser(ctx, container, *username)
+	if err != nil {
+		return err
+	}
+
+	if err := container.SessionService.RevokeAllUserSessions(ctx, domain.NewSystemActor(), user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("ended every session of %s\n", user.Username)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+// runSetup handles the setup command, which creates the first
+// administrator from the command line. Details not given as flags are
+// prompted for, and the password is read without echoing it.
+func runSetup(args []string) error {
+	var request domain.UserCreate
+
+	flags := config.NewFlagSet("setup")
+	userDetailFlags(flags, &request)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	required, err := container.SetupService.IsRequired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !required {
+		return errors.New("setup has already been completed")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+
+	if err := promptUserDetails(reader, &request); err != nil {
+		return err
+	}
+
+	request.Password, err = promptPassword(reader)
+	if err != nil {
+		return err
+	}
+
+	id, err := container.SetupService.CreateInitialAdministrator(ctx, request, cliClient)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created administrator %s (%s)\n", request.Username, id)
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+var userCommands = map[string]func(args []string) error{
+	"create":         runUserCreate,
+	"list":           runUserList,
+	"disable":        runUserDisable,
+	"reset-password": runUserResetPassword,
+}
+
+// runUser handles the user command.
+func runUser(args []string) error {
+	return runSubcommand(subcommandUsage("user", userCommands), args, userCommands)
+}
+
+// runUserCreate creates a user with the Basic User role. Details not given
+// as flags are prompted for, and the password is read without echoing it.
+func runUserCreate(args []string) error {
+	var request domain.UserCreate
+
+	flags := config.NewFlagSet("user create")
+	userDetailFlags(flags, &request)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	reader := bufio.NewReader(os.Stdin)
+
+	if err := promptUserDetails(reader, &request); err != nil {
+		return err
+	}
+
+	request.Password, err = promptPassword(reader)
+	if err != nil {
+		return err
+	}
+
+	if err := request.Validate(); err != nil {
+		return err
+	}
+
+	id, err := container.UserService.Create(ctx, domain.NewSystemActor(), request, cliClient)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created user %s (%s)\n", request.Username, id)
+	return nil
+}
+
+// runUserList prints one page of users using the same filters as the API.
+func runUserList(args []string) error {
+	query := domain.NewUserQuery()
+
+	flags := config.NewFlagSet("user list")
+	flags.StringVar(&query.Search, "search", "", "only users whose name, username or email contains this text")
+	flags.BoolVar(&query.Deleted, "deleted", false, "list soft deleted users instead")
+	flags.IntVar(&query.Page, "page", query.Page, "page number, starting at 1")
+	flags.IntVar(&query.PageSize, "page-size", query.PageSize, "users per page")
+	flags.StringVar(&query.Sort, "sort", query.Sort, "field to sort by")
+	flags.StringVar(&query.Order, "order", query.Order, "asc or desc")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := query.Validate(); err != nil {
+		return err
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	page, err := container.UserService.GetAll(ctx, domain.NewSystemActor(), query)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tUSERNAME\tEMAIL\tROLES\tSTATUS")
+
+	for _, user := range page.Items {
+		roles := make([]string, len(user.Roles))
+		for idx, role := range user.Roles {
+			roles[idx] = role.Name
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", user.ID, user.Username, user.Email, strings.Join(roles, ", "), userStatus(user))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\npage %d, %d of %d users\n", page.Page, len(page.Items), page.Total)
+	return nil
+}
+
+func userStatus(user domain.UserRead) string {
+	switch {
+	case user.DeletedAt != nil:
+		return "deleted"
+	case user.IsDisabled:
+		return "disabled"
+	default:
+		return "active"
+	}
+}
+
+// runUserDisable disables a user and ends their sessions.
+func runUserDisable(args []string) error {
+	flags := config.NewFlagSet("user disable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: mainframe user disable <username>")
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	user, err := findUser(ctx, container, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := container.UserService.Disable(ctx, domain.NewSystemActor(), user.ID, cliClient); err != nil {
+		return err
+	}
+
+	fmt.Printf("disabled %s\n", user.Username)
+	return nil
+}
+
+// runUserResetPassword gives a user a temporary password they must change
+// when they next log in.
+func runUserResetPassword(args []string) error {
+	flags := config.NewFlagSet("user reset-password")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: mainframe user reset-password <username>")
+	}
+
+	container, err := openContainer(flags)
+	if err != nil {
+		return err
+	}
+	defer container.DB.Close()
+
+	ctx := context.Background()
+
+	user, err := findUser(ctx, container, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	response, err := container.UserService.ResetPassword(ctx, domain.NewSystemActor(), user.ID, cliClient)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("temporary password for %s: %s\n", user.Username, response.TemporaryPassword)
+	return nil
+}
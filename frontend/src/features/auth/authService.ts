@@ -1,7 +1,15 @@
 import * as v from "valibot";
 import { httpClient } from "@/lib/httpClient";
 import { handleApiRequest } from "@/lib/apiHelpers";
-import { type LoginResponse, LoginResponseSchema, type SignInRequest } from "@/features/auth/types";
+import {
+  CsrfTokenResponseSchema,
+  type LoginResponse,
+  LoginResponseSchema,
+  type MFASignInRequest,
+  MFARequiredResponseSchema,
+  type SignInRequest,
+  type SignInResult,
+} from "@/features/auth/types";
 
 // @ts-ignore
 import type { ApiError } from "@/lib/apiHelpers";
@@ -10,23 +18,55 @@ import type { ValiError } from "valibot";
 
 export interface IAuthService {
   /**
-   * Sign in a user with username and password
+   * Sign in a user with username and password. When the user has MFA enabled
+   * the result holds a challenge token to pass to verifyMfa instead of the user.
    * @throws {ApiError} When authentication fails or server returns an error
    * @throws {ValiError} When the server response doesn't match the expected schema.
    */
-  signIn(request: SignInRequest): Promise<LoginResponse>;
+  signIn(request: SignInRequest): Promise<SignInResult>;
+
+  /**
+   * Finish signing in a user with MFA enabled using the code from their
+   * authenticator app or a recovery code
+   * @throws {ApiError} When the code or challenge is invalid or has expired
+   * @throws {ValiError} When the server response doesn't match the expected schema.
+   */
+  verifyMfa(request: MFASignInRequest): Promise<LoginResponse>;
 
   /**
    * Sign out the current user
    * @throws {ApiError} When the request fails
    */
   signOut(): Promise<void>;
+
+  /**
+   * Get the CSRF token for the current session. httpClient already sends it
+   * from the csrf_token cookie, so this is only needed when the cookie can't be read.
+   * @throws {ApiError} When there is no session
+   * @throws {ValiError} When the server response doesn't match the expected schema.
+   */
+  getCsrfToken(): Promise<string>;
 }
 
 export const authService: IAuthService = {
   signIn: async (request) => {
+    return handleApiRequest(async (): Promise<SignInResult> => {
+      const response = await httpClient.post("auth/login", { json: request });
+      const body = await response.json();
+
+      // 202 Accepted means the password was right but a second factor is needed.
+      if (response.status === 202) {
+        const challenge = v.parse(MFARequiredResponseSchema, body);
+        return { status: "mfaRequired", challengeToken: challenge.challengeToken };
+      }
+
+      return { status: "signedIn", user: v.parse(LoginResponseSchema, body) };
+    });
+  },
+
+  verifyMfa: async (request) => {
     return handleApiRequest(async () => {
-      const response = await httpClient.post("auth/login", { json: request }).json();
+      const response = await httpClient.post("auth/login/mfa", { json: request }).json();
 
       return v.parse(LoginResponseSchema, response);
     });
@@ -37,4 +77,12 @@ export const authService: IAuthService = {
       await httpClient.post("auth/logout");
     });
   },
+
+  getCsrfToken: async () => {
+    return handleApiRequest(async () => {
+      const response = await httpClient.get("auth/csrf").json();
+
+      return v.parse(CsrfTokenResponseSchema, response).csrfToken;
+    });
+  },
 };
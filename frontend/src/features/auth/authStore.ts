@@ -10,11 +10,29 @@ type AuthStore = {
   error: string | null;
 
   /**
-   * Sign in a user with username and password
+   * The challenge token while a user with MFA enabled still has to enter
+   * a code. The sign in form shows the MFA step whenever this is set.
+   */
+  mfaChallenge: string | null;
+
+  /**
+   * Sign in a user with username and password. Users with MFA enabled are
+   * moved to the MFA step instead of being signed in.
    * @throws {ApiError} When authentication fails
    */
   signIn: (request: SignInRequest, service?: IAuthService) => Promise<void>;
 
+  /**
+   * Finish signing in at the MFA step with a TOTP or recovery code
+   * @throws {ApiError} When the code is wrong or the challenge has expired
+   */
+  verifyMfa: (code: string, service?: IAuthService) => Promise<void>;
+
+  /**
+   * Leave the MFA step and go back to the username and password form
+   */
+  cancelMfa: () => void;
+
   /**
    * Sign out the current user
    */
@@ -26,24 +44,54 @@ type AuthStore = {
   checkAuth: (service?: IAuthService) => Promise<void>;
 };
 
-export const useAuthStore = create<AuthStore>((set) => ({
+export const useAuthStore = create<AuthStore>((set, get) => ({
   user: null,
   isLoading: true,
   error: null,
+  mfaChallenge: null,
 
   signIn: async (request, service = authService) => {
+    try {
+      set({ error: null, mfaChallenge: null });
+      const result = await service.signIn(request);
+      if (result.status === "mfaRequired") {
+        set({ user: null, mfaChallenge: result.challengeToken });
+        return;
+      }
+
+      const user = new AuthenticatedUser(result.user);
+      set({ user, error: null });
+    } catch (error) {
+      const message = error instanceof ApiError ? error.message : "Sign in failed";
+      set({ error: message, user: null });
+      throw error;
+    }
+  },
+
+  verifyMfa: async (code, service = authService) => {
+    const challengeToken = get().mfaChallenge;
+    if (!challengeToken) {
+      throw new Error("There is no sign in waiting for a code");
+    }
+
     try {
       set({ error: null });
-      const response = await service.signIn(request);
+      const response = await service.verifyMfa({ challengeToken, code });
       const user = new AuthenticatedUser(response);
-      set({ user, error: null });
+      set({ user, error: null, mfaChallenge: null });
     } catch (error) {
+      // The challenge stays so the user can retry a mistyped code. When it
+      // has expired they go back to the password form with cancelMfa.
       const message = error instanceof ApiError ? error.message : "Sign in failed";
       set({ error: message, user: null });
       throw error;
     }
   },
 
+  cancelMfa: () => {
+    set({ mfaChallenge: null, error: null });
+  },
+
   signOut: async (service = authService) => {
     try {
       await service.signOut();
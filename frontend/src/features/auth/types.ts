@@ -1,7 +1,8 @@
 import * as v from "valibot";
 
 /**
- * The only valid role names in the system.
+ * The names of the built-in roles. Administrators can add custom roles
+ * with other names.
  */
 export const ROLES = {
   Administrator: "Administrator",
@@ -17,16 +18,34 @@ export const RoleNameSchema = v.picklist(
 );
 
 /**
- * A limited set of specific roles that exist on the backend.
+ * The built-in roles that always exist on the backend.
  */
 export type RoleName = typeof ROLES[keyof typeof ROLES];
 
+/**
+ * Permissions that roles can grant on the backend.
+ */
+export const PERMISSIONS = {
+  UsersRead: "users:read",
+  UsersWrite: "users:write",
+  RolesRead: "roles:read",
+  RolesWrite: "roles:write",
+  RecipesRead: "recipes:read",
+  RecipesWrite: "recipes:write",
+  AuditRead: "audit:read",
+} as const;
+
+export type Permission = typeof PERMISSIONS[keyof typeof PERMISSIONS];
+
 /**
  * Role schema for validation of roles
  */
 export const RoleSchema = v.object({
   id: v.pipe(v.string(), v.uuid()),
-  name: RoleNameSchema,
+  name: v.string(), // Custom roles can have any name
+  description: v.string(),
+  isBuiltIn: v.boolean(),
+  permissions: v.array(v.string()),
   // Add other role fields as needed
 });
 
@@ -89,11 +108,41 @@ export const LoginResponseSchema = v.object({
       v.transform((str) => new Date(str))
     )
   ),
-  roles: v.array(RoleNameSchema), // Just role names, not full Role objects
+  roles: v.array(v.string()), // Just role names, not full Role objects
+  permissions: v.array(v.string()), // Every permission granted by the roles
 });
 
 export type LoginResponse = v.InferOutput<typeof LoginResponseSchema>;
 
+/**
+ * MFA required response schema matching the backend MFARequiredResponse struct.
+ * The login returns this with a 202 instead of a session when the user has
+ * multi-factor authentication enabled.
+ */
+export const MFARequiredResponseSchema = v.object({
+  mfaRequired: v.literal(true),
+  challengeToken: v.string(),
+});
+
+export type MFARequiredResponse = v.InferOutput<typeof MFARequiredResponseSchema>;
+
+/**
+ * The outcome of signing in with a username and password. Either the user
+ * is signed in, or they still need to enter a code for the challenge.
+ */
+export type SignInResult =
+  | { status: "signedIn"; user: LoginResponse }
+  | { status: "mfaRequired"; challengeToken: string };
+
+/**
+ * CSRF token response schema matching the backend CSRFTokenResponse struct.
+ */
+export const CsrfTokenResponseSchema = v.object({
+  csrfToken: v.string(),
+});
+
+export type CsrfTokenResponse = v.InferOutput<typeof CsrfTokenResponseSchema>;
+
 /**
  * Sign in request payload
  */
@@ -102,6 +151,15 @@ export type SignInRequest = {
   password: string;
 };
 
+/**
+ * MFA sign in request payload. The code can be a TOTP code or an unused
+ * recovery code.
+ */
+export type MFASignInRequest = {
+  challengeToken: string;
+  code: string;
+};
+
 /**
  * AuthenticatedUser is the details of a user who has been successfully
  * logged into the application. The only way to create one is by getting
@@ -114,6 +172,7 @@ export class AuthenticatedUser {
   lastName: string;
   lastLogin: Date | null;
   roles: string[];
+  permissions: string[];
 
   constructor(loginResponse: LoginResponse) {
     this.username = loginResponse.username;
@@ -122,12 +181,17 @@ export class AuthenticatedUser {
     this.lastName = loginResponse.lastName;
     this.lastLogin = loginResponse.lastLogin;
     this.roles = loginResponse.roles;
+    this.permissions = loginResponse.permissions;
   }
 
   hasRole(name: RoleName): boolean {
     return this.roles.includes(name);
   }
 
+  can(permission: Permission): boolean {
+    return this.permissions.includes(permission);
+  }
+
   isAdmin(): boolean {
     return this.hasRole(ROLES.Administrator);
   }
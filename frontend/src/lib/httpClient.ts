@@ -1,8 +1,41 @@
 import ky from "ky";
 
+/** The cookie the backend stores the CSRF token in alongside the session. */
+export const CSRF_COOKIE_NAME = "csrf_token";
+
+/** The header the backend expects the CSRF token in on unsafe requests. */
+export const CSRF_HEADER_NAME = "X-CSRF-Token";
+
+const SAFE_METHODS = ["GET", "HEAD", "OPTIONS"];
+
+/**
+ * Reads the CSRF token from the cookie set by the backend at login.
+ * Returns null when there is no session.
+ */
+export function readCsrfToken(): string | null {
+  const prefix = `${CSRF_COOKIE_NAME}=`;
+  const cookie = document.cookie.split("; ").find((c) => c.startsWith(prefix));
+  return cookie ? decodeURIComponent(cookie.slice(prefix.length)) : null;
+}
+
+/**
+ * Adds the CSRF token header to every request that can change state.
+ */
+function attachCsrfToken(request: Request) {
+  if (SAFE_METHODS.includes(request.method.toUpperCase())) {
+    return;
+  }
+
+  const token = readCsrfToken();
+  if (token) {
+    request.headers.set(CSRF_HEADER_NAME, token);
+  }
+}
+
 /**
  * httpClient is used to make HTTP requests to the backend API.
  * All requests are automatically prefixed with '/api' and include credentials (cookies).
+ * POST, PUT and DELETE requests also send the CSRF token from the csrf_token cookie.
  *
  * @example
  * // POST to /api/auth/signout
@@ -16,4 +49,7 @@ export const httpClient = ky.create({
   prefixUrl: "/api",
   credentials: "include",
   timeout: 10_000,
+  hooks: {
+    beforeRequest: [attachCsrfToken],
+  },
 });
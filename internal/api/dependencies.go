@@ -0,0 +1,49 @@
+package api
+
+import (
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/mail"
+	"github.com/th3oth3rjak3/mainframe/internal/ratelimit"
+)
+
+// newKeyring loads the server keys. A keyring file takes priority over the
+// single server key.
+func newKeyring(cfg config.KeysConfig) (*crypto.Keyring, error) {
+	if cfg.KeyringFile != "" {
+		return crypto.LoadKeyringFile(cfg.KeyringFile)
+	}
+
+	return crypto.NewSingleKeyring(cfg.ServerKey)
+}
+
+// newRateLimitStore chooses where rate limit buckets are kept.
+func newRateLimitStore(db *sqlx.DB, cfg config.RateLimitConfig) ratelimit.Store {
+	switch cfg.Store {
+	case "sqlite":
+		return ratelimit.NewSQLiteStore(db)
+	default:
+		return ratelimit.NewMemoryStore()
+	}
+}
+
+// newMailer chooses how email is delivered.
+func newMailer(cfg config.MailConfig, logger *slog.Logger) mail.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	case "file":
+		return mail.NewFileMailer(cfg.FilePath)
+	default:
+		return mail.NewLogMailer(logger)
+	}
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"embed"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
 	_ "github.com/th3oth3rjak3/mainframe/internal/docs"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
 	"github.com/th3oth3rjak3/mainframe/internal/handler"
@@ -24,11 +28,14 @@ import (
 type Server struct {
 	router    *fiber.App
 	container *ServiceContainer
-	hmacKey   string
+	config    *config.Config
+
+	// metricsServer serves /metrics when it has its own address.
+	metricsServer *http.Server
 }
 
 // NewServer creates a new Server instance and configures its routes.
-func NewServer(container *ServiceContainer, hmacKey string, webAssets embed.FS) *Server {
+func NewServer(container *ServiceContainer, cfg *config.Config, webAssets embed.FS) *Server {
 	app := fiber.New(fiber.Config{
 		ErrorHandler:          customErrorHandler,
 		Immutable:             true, // Context safety!
@@ -36,24 +43,37 @@ func NewServer(container *ServiceContainer, hmacKey string, webAssets embed.FS)
 		WriteTimeout:          60 * time.Second,
 		IdleTimeout:           60 * time.Second,
 		DisableStartupMessage: false, // Keep that nice Fiber banner
+
+		// Only trusted proxies can choose the IP address rate limits and
+		// the audit log see.
+		ProxyHeader:             cfg.Server.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	// Create the server instance
 	s := &Server{
 		container: container,
 		router:    app,
-		hmacKey:   hmacKey,
+		config:    cfg,
 	}
 
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:8080, http://127.0.0.1:8080",
+		AllowOrigins:     strings.Join(cfg.Server.CORSOrigins, ", "),
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-CSRF-Token, X-Request-ID, Traceparent, Tracestate",
+		ExposeHeaders:    "X-Request-ID",
 		AllowCredentials: true,
 	}))
 
 	// Attach middleware
-	s.router.Use(logger.New())
+	s.router.Use(mw.RequestID)
+	if cfg.Metrics.Enabled {
+		s.router.Use(mw.Metrics(container.Metrics))
+	}
+	s.router.Use(mw.Tracing)
+	s.router.Use(mw.RequestLogger(container.Logger))
 	s.router.Use(recover.New())
 
 	// Register routes
@@ -70,13 +90,28 @@ func NewServer(container *ServiceContainer, hmacKey string, webAssets embed.FS)
 	return s
 }
 
-// Start runs the HTTP server on the given address.
-func (s *Server) Start(addr string) error {
-	return s.router.Listen(addr)
+// Start runs the HTTP server on the configured address, along with the
+// metrics listener when it has its own address.
+func (s *Server) Start() error {
+	if s.metricsServer != nil {
+		go func() {
+			err := s.metricsServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.container.Logger.Error("metrics server stopped unexpectedly", "error", err)
+			}
+		}()
+	}
+
+	return s.router.Listen(s.config.Server.Address)
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.router.ShutdownWithContext(ctx)
+	var metricsErr error
+	if s.metricsServer != nil {
+		metricsErr = s.metricsServer.Shutdown(ctx)
+	}
+
+	return errors.Join(s.router.ShutdownWithContext(ctx), metricsErr)
 }
 
 // registerRoutes sets up all the HTTP routes for the application.
@@ -84,20 +119,26 @@ func (s *Server) registerRoutes() {
 	authMiddleware := mw.NewAuthMiddleware(
 		s.container.SessionRepository,
 		s.container.UserRepository,
+		s.container.AccessTokenRepository,
 		s.container.CookieService,
-		s.hmacKey,
+		s.container.Keyring,
+		s.config.Session.Duration,
 	)
 
 	s.registerHealthCheckRoute()
+	s.registerMetricsRoute()
 	s.registerDocumentationRoutes()
 
 	apiGroup := s.router.Group("/api")
+	s.registerSetupRoutes(apiGroup)
 	s.registerAuthenticationRoutes(apiGroup, authMiddleware)
+	s.registerProfileRoutes(apiGroup, authMiddleware)
 
-	// Routes below here are all protected
-	protectedGroup := apiGroup.Group("", authMiddleware.SessionAuth)
+	// Routes below here are all protected and accept either a session or an access token
+	protectedGroup := apiGroup.Group("", authMiddleware.Authenticate, mw.BlockPendingPasswordChange)
 	s.registerUserRoutes(protectedGroup)
 	s.registerRoleRoutes(protectedGroup)
+	s.registerAuditRoutes(protectedGroup)
 
 }
 
@@ -123,14 +164,22 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 	switch {
 	case errors.Is(err, shared.ErrNotFound):
 		code = fiber.StatusNotFound
-	case errors.Is(err, shared.ErrUsernameTaken):
+	case errors.Is(err, shared.ErrUsernameTaken), errors.Is(err, shared.ErrRoleNameTaken), errors.Is(err, shared.ErrRoleInUse):
 		code = fiber.StatusConflict
-	case errors.Is(err, shared.ErrForbidden):
+	case errors.Is(err, shared.ErrForbidden), errors.Is(err, shared.ErrAccountDisabled):
 		code = fiber.StatusForbidden
 	case errors.Is(err, shared.ErrUnauthorized), errors.Is(err, shared.ErrInvalidCredentials):
 		code = fiber.StatusUnauthorized
 	case errors.Is(err, shared.ErrBadRequest):
 		code = fiber.StatusBadRequest
+	case errors.Is(err, shared.ErrTooManyRequests):
+		code = fiber.StatusTooManyRequests
+	}
+
+	var rateLimitErr *shared.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		seconds := int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(seconds, 1)))
 	}
 
 	// Return JSON response
@@ -144,6 +193,28 @@ func (s *Server) registerHealthCheckRoute() {
 	s.router.Get("/health", handler.HandleHealthCheck)
 }
 
+// registerMetricsRoute exposes the Prometheus metrics when they are
+// enabled, either on the main router or on a listener of their own.
+func (s *Server) registerMetricsRoute() {
+	if !s.config.Metrics.Enabled {
+		return
+	}
+
+	if s.config.Metrics.Address == "" {
+		s.router.Get("/metrics", adaptor.HTTPHandler(s.container.Metrics.Handler()))
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", s.container.Metrics.Handler())
+
+	s.metricsServer = &http.Server{
+		Addr:              s.config.Metrics.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
 // registerDocutnationRoutes registers the open api and scalar documentation endpoints
 // with the server router.
 func (s *Server) registerDocumentationRoutes() {
@@ -151,15 +222,34 @@ func (s *Server) registerDocumentationRoutes() {
 	s.router.Get("/docs", handler.HandleDocs)
 }
 
+// registerSetupRoutes registers the routes used to create the first
+// administrator. They are not protected because no users exist yet.
+func (s *Server) registerSetupRoutes(router fiber.Router) {
+	setupGroup := router.Group("/setup")
+	setupGroup.Get("", func(c *fiber.Ctx) error {
+		return handler.HandleGetSetupStatus(c, s.container.SetupService)
+	})
+	setupGroup.Post("", mw.RateLimitByIP(s.container.SetupIPLimiter), func(c *fiber.Ctx) error {
+		return handler.HandleSetup(c, s.container.SetupService)
+	})
+}
+
 // registerAuthenticationRoutes registers all the routes associated with authentication
 func (s *Server) registerAuthenticationRoutes(router fiber.Router, authMiddleware *mw.AuthMiddleware) {
 	authGroup := router.Group("/auth")
 
+	loginRateLimit := mw.LoginRateLimit(s.container.LoginIPLimiter, s.container.LoginUsernameLimiter)
+	mfaRateLimit := mw.RateLimitByIP(s.container.MFAIPLimiter)
+
 	// Not protected on purpose to allow login
-	authGroup.Post("/login", func(c *fiber.Ctx) error {
+	authGroup.Post("/login", loginRateLimit, func(c *fiber.Ctx) error {
 		return handler.HandleLogin(c, s.container.AuthenticationService, s.container.CookieService)
 	})
 
+	authGroup.Post("/login/mfa", mfaRateLimit, func(c *fiber.Ctx) error {
+		return handler.HandleMFALogin(c, s.container.AuthenticationService, s.container.CookieService)
+	})
+
 	authGroup.Post("/logout",
 		authMiddleware.SessionAuth,
 		func(c *fiber.Ctx) error {
@@ -167,36 +257,199 @@ func (s *Server) registerAuthenticationRoutes(router fiber.Router, authMiddlewar
 		})
 
 	authGroup.Get("/me", authMiddleware.SessionAuth, handler.HandleRefreshLoginDetails)
+
+	authGroup.Get("/csrf", authMiddleware.SessionAuth, func(c *fiber.Ctx) error {
+		return handler.HandleGetCSRFToken(c, s.container.CookieService)
+	})
+
+	authGroup.Put("/password", authMiddleware.SessionAuth, func(c *fiber.Ctx) error {
+		return handler.HandleChangePassword(c, s.container.AuthenticationService)
+	})
+
+	// Not protected on purpose to allow users who can't log in to reset their password
+	passwordResetRateLimit := mw.PasswordResetRateLimit(s.container.ResetIPLimiter, s.container.ResetEmailLimiter)
+
+	authGroup.Post("/password/forgot", passwordResetRateLimit, func(c *fiber.Ctx) error {
+		return handler.HandleForgotPassword(c, s.container.PasswordResetService)
+	})
+	authGroup.Post("/password/reset", mw.RateLimitByIP(s.container.ResetIPLimiter), func(c *fiber.Ctx) error {
+		return handler.HandleResetPassword(c, s.container.PasswordResetService)
+	})
+
+	mfaGroup := authGroup.Group("/mfa", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange)
+	mfaGroup.Post("/enroll", func(c *fiber.Ctx) error {
+		return handler.HandleBeginMFAEnrollment(c, s.container.MFAService)
+	})
+	mfaGroup.Post("/confirm", func(c *fiber.Ctx) error {
+		return handler.HandleConfirmMFAEnrollment(c, s.container.MFAService)
+	})
+	mfaGroup.Post("/disable", func(c *fiber.Ctx) error {
+		return handler.HandleDisableMFA(c, s.container.MFAService)
+	})
+
+	sessionsGroup := authGroup.Group("/sessions", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange)
+	sessionsGroup.Get("", func(c *fiber.Ctx) error {
+		return handler.HandleListOwnSessions(c, s.container.SessionService)
+	})
+	sessionsGroup.Delete("", func(c *fiber.Ctx) error {
+		return handler.HandleRevokeOtherSessions(c, s.container.SessionService)
+	})
+	sessionsGroup.Delete("/:id", func(c *fiber.Ctx) error {
+		return handler.HandleRevokeOwnSession(c, s.container.SessionService, s.container.CookieService)
+	})
+
+	tokensGroup := authGroup.Group("/tokens", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange)
+	tokensGroup.Get("", func(c *fiber.Ctx) error {
+		return handler.HandleListAccessTokens(c, s.container.AccessTokenService)
+	})
+	tokensGroup.Post("", func(c *fiber.Ctx) error {
+		return handler.HandleCreateAccessToken(c, s.container.AccessTokenService)
+	})
+	tokensGroup.Delete("/:id", func(c *fiber.Ctx) error {
+		return handler.HandleRevokeAccessToken(c, s.container.AccessTokenService)
+	})
+
+	s.registerWebAuthnRoutes(authGroup, authMiddleware)
+}
+
+// registerWebAuthnRoutes registers the passkey ceremony and credential
+// management routes. Login routes are not protected on purpose.
+func (s *Server) registerWebAuthnRoutes(router fiber.Router, authMiddleware *mw.AuthMiddleware) {
+	webAuthnGroup := router.Group("/webauthn")
+
+	// Beginning a login saves a ceremony, so both steps are limited.
+	passkeyRateLimit := mw.RateLimitByIP(s.container.PasskeyIPLimiter)
+
+	webAuthnGroup.Post("/login/begin", passkeyRateLimit, func(c *fiber.Ctx) error {
+		return handler.HandleBeginWebAuthnLogin(c, s.container.WebAuthnService)
+	})
+	webAuthnGroup.Post("/login/finish", passkeyRateLimit, func(c *fiber.Ctx) error {
+		return handler.HandleFinishWebAuthnLogin(c, s.container.AuthenticationService, s.container.CookieService)
+	})
+
+	webAuthnGroup.Post("/register/begin", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange, func(c *fiber.Ctx) error {
+		return handler.HandleBeginWebAuthnRegistration(c, s.container.WebAuthnService)
+	})
+	webAuthnGroup.Post("/register/finish", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange, func(c *fiber.Ctx) error {
+		return handler.HandleFinishWebAuthnRegistration(c, s.container.WebAuthnService)
+	})
+
+	credentialsGroup := webAuthnGroup.Group("/credentials", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange)
+	credentialsGroup.Get("", func(c *fiber.Ctx) error {
+		return handler.HandleListWebAuthnCredentials(c, s.container.WebAuthnService)
+	})
+	credentialsGroup.Put("/:id", func(c *fiber.Ctx) error {
+		return handler.HandleRenameWebAuthnCredential(c, s.container.WebAuthnService)
+	})
+	credentialsGroup.Delete("/:id", func(c *fiber.Ctx) error {
+		return handler.HandleDeleteWebAuthnCredential(c, s.container.WebAuthnService)
+	})
+}
+
+// registerProfileRoutes registers the routes users use to manage their own
+// details. They only need to be logged in, not to have any permission.
+func (s *Server) registerProfileRoutes(router fiber.Router, authMiddleware *mw.AuthMiddleware) {
+	meGroup := router.Group("/me", authMiddleware.SessionAuth, mw.BlockPendingPasswordChange)
+	meGroup.Get("/profile", func(c *fiber.Ctx) error {
+		return handler.HandleGetProfile(c, s.container.UserService)
+	})
+	meGroup.Put("/profile", func(c *fiber.Ctx) error {
+		return handler.HandleUpdateProfile(c, s.container.UserService)
+	})
 }
 
 // registerUserRoutes registers all the routes associated with users.
 // The router is expected to be protected by authentication middleware.
 func (s *Server) registerUserRoutes(router fiber.Router) {
-	adminRoleRequired := mw.RequireRole(domain.Administrator)
-	usersGroup := router.Group("/users", adminRoleRequired)
-	usersGroup.Get("", func(c *fiber.Ctx) error {
+	canRead := mw.RequirePermission(domain.PermissionUsersRead)
+	canWrite := mw.RequirePermission(domain.PermissionUsersWrite)
+
+	usersGroup := router.Group("/users", mw.RequireScope(domain.ScopeUsers))
+	usersGroup.Get("", canRead, func(c *fiber.Ctx) error {
 		return handler.HandleListUsers(c, s.container.UserService)
 	})
-	usersGroup.Get("/:id", func(c *fiber.Ctx) error {
+	usersGroup.Get("/:id", canRead, func(c *fiber.Ctx) error {
 		return handler.HandleGetUserByID(c, s.container.UserService)
 	})
-	usersGroup.Post("", func(c *fiber.Ctx) error {
+	usersGroup.Post("", canWrite, func(c *fiber.Ctx) error {
 		return handler.HandleCreateUser(c, s.container.UserService)
 	})
-	usersGroup.Put("/:id", func(c *fiber.Ctx) error {
+	usersGroup.Put("/:id", canWrite, func(c *fiber.Ctx) error {
 		return handler.HandleUpdateUser(c, s.container.UserService)
 	})
-	usersGroup.Delete("/:id", func(c *fiber.Ctx) error {
+	usersGroup.Delete("/:id", canWrite, func(c *fiber.Ctx) error {
 		return handler.HandleDeleteUser(c, s.container.UserService)
 	})
+	usersGroup.Post("/:id/restore", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleRestoreUser(c, s.container.UserService)
+	})
+	usersGroup.Delete("/:id/purge", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandlePurgeUser(c, s.container.UserService)
+	})
+	usersGroup.Post("/:id/password-reset", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleResetUserPassword(c, s.container.UserService)
+	})
+	usersGroup.Post("/:id/unlock", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleUnlockUser(c, s.container.UserService)
+	})
+	usersGroup.Post("/:id/disable", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleDisableUser(c, s.container.UserService)
+	})
+	usersGroup.Post("/:id/enable", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleEnableUser(c, s.container.UserService)
+	})
+	usersGroup.Put("/:id/roles", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleSetUserRoles(c, s.container.UserService)
+	})
+	usersGroup.Post("/:id/roles/:roleId", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleAddUserRole(c, s.container.UserService)
+	})
+	usersGroup.Delete("/:id/roles/:roleId", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleRemoveUserRole(c, s.container.UserService)
+	})
+	usersGroup.Get("/:id/sessions", canRead, func(c *fiber.Ctx) error {
+		return handler.HandleListUserSessions(c, s.container.SessionService)
+	})
+	usersGroup.Delete("/:id/sessions", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleRevokeAllUserSessions(c, s.container.SessionService)
+	})
+	usersGroup.Delete("/:id/sessions/:sessionId", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleRevokeUserSession(c, s.container.SessionService)
+	})
 }
 
 // registerRoleRoutes registers all the routes associated with roles.
 // The router is expectecd to be protected by authentication middleware.
 func (s *Server) registerRoleRoutes(router fiber.Router) {
-	adminRoleRequired := mw.RequireRole(domain.Administrator)
-	rolesGroup := router.Group("/roles", adminRoleRequired)
-	rolesGroup.Get("", func(c *fiber.Ctx) error {
+	canRead := mw.RequirePermission(domain.PermissionRolesRead)
+	canWrite := mw.RequirePermission(domain.PermissionRolesWrite)
+
+	rolesGroup := router.Group("/roles", mw.RequireScope(domain.ScopeRoles))
+	rolesGroup.Get("", canRead, func(c *fiber.Ctx) error {
 		return handler.HandleListRoles(c, s.container.RoleService)
 	})
+	rolesGroup.Get("/:id", canRead, func(c *fiber.Ctx) error {
+		return handler.HandleGetRoleByID(c, s.container.RoleService)
+	})
+	rolesGroup.Post("", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleCreateRole(c, s.container.RoleService)
+	})
+	rolesGroup.Put("/:id", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleUpdateRole(c, s.container.RoleService)
+	})
+	rolesGroup.Delete("/:id", canWrite, func(c *fiber.Ctx) error {
+		return handler.HandleDeleteRole(c, s.container.RoleService)
+	})
+}
+
+// registerAuditRoutes registers the routes used to read the audit log.
+// The router is expected to be protected by authentication middleware.
+func (s *Server) registerAuditRoutes(router fiber.Router) {
+	auditGroup := router.Group("/audit", mw.RequireScope(domain.ScopeAudit), mw.RequirePermission(domain.PermissionAuditRead))
+	auditGroup.Get("", func(c *fiber.Ctx) error {
+		return handler.HandleListAuditEvents(c, s.container.AuditService)
+	})
+	auditGroup.Get("/export", func(c *fiber.Ctx) error {
+		return handler.HandleExportAuditEvents(c, s.container.AuditService)
+	})
 }
@@ -1,8 +1,15 @@
 package api
 
 import (
+	"fmt"
+	"log/slog"
+
 	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/metrics"
+	"github.com/th3oth3rjak3/mainframe/internal/ratelimit"
 	"github.com/th3oth3rjak3/mainframe/internal/repository"
 	"github.com/th3oth3rjak3/mainframe/internal/services"
 )
@@ -10,47 +17,169 @@ import (
 type ServiceContainer struct {
 	// Infrastructure
 	DB             *sqlx.DB
+	Logger         *slog.Logger
+	Metrics        *metrics.Metrics
+	Keyring        *crypto.Keyring
 	PasswordHasher domain.PasswordHasher
 
+	// Rate limiters
+	LoginIPLimiter       *ratelimit.Limiter
+	LoginUsernameLimiter *ratelimit.Limiter
+	SetupIPLimiter       *ratelimit.Limiter
+	MFAIPLimiter         *ratelimit.Limiter
+	PasskeyIPLimiter     *ratelimit.Limiter
+	ResetIPLimiter       *ratelimit.Limiter
+	ResetEmailLimiter    *ratelimit.Limiter
+
 	// Repositories
-	UserRepository    repository.UserRepository
-	SessionRepository repository.SessionRepository
-	RoleRepository    repository.RoleRepository
+	UserRepository          repository.UserRepository
+	SessionRepository       repository.SessionRepository
+	RoleRepository          repository.RoleRepository
+	MFARepository           repository.MFARepository
+	WebAuthnRepository      repository.WebAuthnRepository
+	PasswordResetRepository repository.PasswordResetRepository
+	AccessTokenRepository   repository.AccessTokenRepository
+	AuditRepository         repository.AuditRepository
+	KeyRepository           repository.KeyRepository
 
 	// Services
 	UserService           services.UserService
 	AuthenticationService services.AuthenticationService
 	CookieService         services.CookieService
 	RoleService           services.RoleService
+	MFAService            services.MFAService
+	WebAuthnService       services.WebAuthnService
+	PasswordResetService  services.PasswordResetService
+	SessionService        services.SessionService
+	AccessTokenService    services.AccessTokenService
+	AuditService          services.AuditService
+	SetupService          services.SetupService
+	SessionCleanupService services.SessionCleanupService
 }
 
 // NewServiceContainer builds and returns a new dependency container.
 // This is the single place where all application components are instantiated.
-func NewServiceContainer(db *sqlx.DB, hmacKey string) (*ServiceContainer, error) {
+func NewServiceContainer(
+	db *sqlx.DB,
+	cfg *config.Config,
+	logger *slog.Logger,
+) (*ServiceContainer, error) {
 	// Infrastructure
+	keyring, err := newKeyring(cfg.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server keys: %w", err)
+	}
+
+	webAuthnConfig := services.WebAuthnConfig{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: "Mainframe",
+		RPOrigins:     cfg.WebAuthn.RPOrigins,
+	}
+
+	loginLimits := cfg.RateLimit.LoginLimits()
+	rateLimitStore := newRateLimitStore(db, cfg.RateLimit)
+	mailer := newMailer(cfg.Mail, logger)
+
+	appMetrics := metrics.New()
+	appMetrics.RegisterDatabase(db)
+
 	pwHasher := domain.NewPasswordHasher()
+	loginIPLimiter := ratelimit.NewLimiter(rateLimitStore, "login:ip", loginLimits.PerIP)
+	loginUsernameLimiter := ratelimit.NewLimiter(rateLimitStore, "login:username", loginLimits.PerUsername)
+	setupIPLimiter := ratelimit.NewLimiter(rateLimitStore, "setup:ip", cfg.RateLimit.SetupIP)
+	mfaIPLimiter := ratelimit.NewLimiter(rateLimitStore, "mfa:ip", cfg.RateLimit.MFAIP)
+	passkeyIPLimiter := ratelimit.NewLimiter(rateLimitStore, "passkey:ip", cfg.RateLimit.PasskeyIP)
+	resetIPLimiter := ratelimit.NewLimiter(rateLimitStore, "password_reset:ip", cfg.RateLimit.ResetIP)
+	resetEmailLimiter := ratelimit.NewLimiter(rateLimitStore, "password_reset:email", cfg.RateLimit.ResetEmail)
 
 	// Repositories
 	userRepo := repository.NewUserRepository(db)
 	sessionRepo := repository.NewSessionRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	webAuthnRepo := repository.NewWebAuthnRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	accessTokenRepo := repository.NewAccessTokenRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	keyRepo := repository.NewKeyRepository(db)
+
+	appMetrics.RegisterActiveSessions(sessionRepo.CountActive, logger)
 
 	// Services
-	userService := services.NewUserService(userRepo, roleRepo, pwHasher)
-	authService := services.NewAuthenticationService(userRepo, sessionRepo, pwHasher, hmacKey)
-	cookieService := services.NewCookieService()
-	roleService := services.NewRoleService(roleRepo)
+	auditService := services.NewAuditService(auditRepo, logger)
+	userService := services.NewUserService(userRepo, roleRepo, sessionRepo, accessTokenRepo, pwHasher, auditService)
+	mfaService := services.NewMFAService(userRepo, mfaRepo, keyRepo, pwHasher, keyring)
+	webAuthnService, err := services.NewWebAuthnService(userRepo, webAuthnRepo, webAuthnConfig)
+	if err != nil {
+		return nil, err
+	}
+	authService := services.NewAuthenticationService(
+		userRepo,
+		sessionRepo,
+		accessTokenRepo,
+		mfaRepo,
+		mfaService,
+		webAuthnService,
+		pwHasher,
+		keyring,
+		cfg.Lockout.Policy(),
+		cfg.Session.Duration,
+		auditService,
+		appMetrics,
+		logger,
+	)
+	cookieService := services.NewCookieService(cfg.IsProduction())
+	roleService := services.NewRoleService(roleRepo, auditService)
+	passwordResetService := services.NewPasswordResetService(
+		userRepo,
+		sessionRepo,
+		accessTokenRepo,
+		passwordResetRepo,
+		mailer,
+		pwHasher,
+		keyring,
+		cfg.Server.BaseURL,
+		logger,
+	)
+	sessionService := services.NewSessionService(sessionRepo, userRepo)
+	accessTokenService := services.NewAccessTokenService(accessTokenRepo, keyring)
+	setupService := services.NewSetupService(userRepo, roleRepo, pwHasher, auditService)
+	sessionCleanupService := services.NewSessionCleanupService(db, appMetrics, logger)
 
 	// Return the fully-built container
 	return &ServiceContainer{
-		DB:                    db,
-		PasswordHasher:        pwHasher,
-		UserRepository:        userRepo,
-		RoleRepository:        roleRepo,
-		SessionRepository:     sessionRepo,
-		UserService:           userService,
-		RoleService:           roleService,
-		AuthenticationService: authService,
-		CookieService:         cookieService,
+		DB:                      db,
+		Logger:                  logger,
+		Metrics:                 appMetrics,
+		Keyring:                 keyring,
+		PasswordHasher:          pwHasher,
+		LoginIPLimiter:          loginIPLimiter,
+		LoginUsernameLimiter:    loginUsernameLimiter,
+		SetupIPLimiter:          setupIPLimiter,
+		MFAIPLimiter:            mfaIPLimiter,
+		PasskeyIPLimiter:        passkeyIPLimiter,
+		ResetIPLimiter:          resetIPLimiter,
+		ResetEmailLimiter:       resetEmailLimiter,
+		UserRepository:          userRepo,
+		RoleRepository:          roleRepo,
+		SessionRepository:       sessionRepo,
+		MFARepository:           mfaRepo,
+		WebAuthnRepository:      webAuthnRepo,
+		PasswordResetRepository: passwordResetRepo,
+		AccessTokenRepository:   accessTokenRepo,
+		AuditRepository:         auditRepo,
+		KeyRepository:           keyRepo,
+		UserService:             userService,
+		RoleService:             roleService,
+		AuthenticationService:   authService,
+		CookieService:           cookieService,
+		MFAService:              mfaService,
+		WebAuthnService:         webAuthnService,
+		PasswordResetService:    passwordResetService,
+		SessionService:          sessionService,
+		AccessTokenService:      accessTokenService,
+		AuditService:            auditService,
+		SetupService:            setupService,
+		SessionCleanupService:   sessionCleanupService,
 	}, nil
 }
@@ -0,0 +1,427 @@
+// Package config loads the application settings. Values are layered: the
+// defaults are overridden by a YAML file, then by environment variables,
+// then by command line flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/ratelimit"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+// redacted replaces secret values when the configuration is dumped.
+const redacted = "[REDACTED]"
+
+type Config struct {
+	// Environment is development or production. Cookies are only marked
+	// secure in production.
+	Environment string          `yaml:"environment"`
+	Server      ServerConfig    `yaml:"server"`
+	Database    DatabaseConfig  `yaml:"database"`
+	Keys        KeysConfig      `yaml:"keys"`
+	Session     SessionConfig   `yaml:"session"`
+	Lockout     LockoutConfig   `yaml:"lockout"`
+	RateLimit   RateLimitConfig `yaml:"rateLimit"`
+	Users       UsersConfig     `yaml:"users"`
+	Mail        MailConfig      `yaml:"mail"`
+	WebAuthn    WebAuthnConfig  `yaml:"webauthn"`
+	Log         LogConfig       `yaml:"log"`
+	Metrics     MetricsConfig   `yaml:"metrics"`
+	Tracing     TracingConfig   `yaml:"tracing"`
+}
+
+type ServerConfig struct {
+	// Address is the host and port the HTTP server listens on.
+	Address string `yaml:"address"`
+
+	// BaseURL is the address users reach the application at. It is used
+	// in links sent by email.
+	BaseURL string `yaml:"baseUrl"`
+
+	// CORSOrigins are the origins allowed to make credentialed requests.
+	CORSOrigins []string `yaml:"corsOrigins"`
+
+	// ProxyHeader is the header a reverse proxy puts the client IP address
+	// in, for example X-Real-IP. Use a header the proxy overwrites rather
+	// than appends to. When empty, the address of the connection is used,
+	// so behind a proxy every client shares the proxy's rate limits.
+	ProxyHeader string `yaml:"proxyHeader"`
+
+	// TrustedProxies are the IP addresses or CIDR ranges of the proxies
+	// allowed to set ProxyHeader. Requests from anywhere else use the
+	// address of the connection.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+type DatabaseConfig struct {
+	Path string `yaml:"path"`
+
+	// AutoMigrate applies pending migrations when the database is opened.
+	AutoMigrate bool `yaml:"autoMigrate"`
+}
+
+// KeysConfig names the server keys used to sign tokens. A keyring file
+// takes priority over a single server key.
+type KeysConfig struct {
+	ServerKey   string `yaml:"serverKey"`
+	KeyringFile string `yaml:"keyringFile"`
+}
+
+type SessionConfig struct {
+	// Duration is how long a session lasts after the last request.
+	Duration time.Duration `yaml:"duration"`
+}
+
+// LockoutConfig is the failed login lockout policy. See domain.LockoutPolicy.
+type LockoutConfig struct {
+	MaxAttempts uint          `yaml:"maxAttempts"`
+	Window      time.Duration `yaml:"window"`
+	MaxWindow   time.Duration `yaml:"maxWindow"`
+}
+
+// Policy returns the lockout policy the configuration describes.
+func (c LockoutConfig) Policy() domain.LockoutPolicy {
+	return domain.LockoutPolicy{
+		MaxAttempts: c.MaxAttempts,
+		BaseWindow:  c.Window,
+		MaxWindow:   c.MaxWindow,
+	}
+}
+
+type RateLimitConfig struct {
+	// Store is memory or sqlite. Buckets in memory are lost on restart.
+	Store         string          `yaml:"store"`
+	LoginIP       ratelimit.Limit `yaml:"loginIp"`
+	LoginUsername ratelimit.Limit `yaml:"loginUsername"`
+
+	// Each unauthenticated flow has its own per IP limit so one can't use up
+	// another's allowance.
+	SetupIP   ratelimit.Limit `yaml:"setupIp"`
+	MFAIP     ratelimit.Limit `yaml:"mfaIp"`
+	PasskeyIP ratelimit.Limit `yaml:"passkeyIp"`
+	ResetIP   ratelimit.Limit `yaml:"resetIp"`
+
+	// ResetEmail limits the password reset emails that can be requested
+	// for one address.
+	ResetEmail ratelimit.Limit `yaml:"resetEmail"`
+}
+
+// LoginLimits returns the login rate limits the configuration describes.
+func (c RateLimitConfig) LoginLimits() ratelimit.LoginLimits {
+	return ratelimit.LoginLimits{
+		PerIP:       c.LoginIP,
+		PerUsername: c.LoginUsername,
+	}
+}
+
+type UsersConfig struct {
+	// RetentionPeriod is how long soft deleted users are kept before they
+	// are purged.
+	RetentionPeriod time.Duration `yaml:"retentionPeriod"`
+}
+
+type MailConfig struct {
+	// Driver is log, file or smtp.
+	Driver   string     `yaml:"driver"`
+	From     string     `yaml:"from"`
+	FilePath string     `yaml:"filePath"`
+	SMTP     SMTPConfig `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// WebAuthnConfig describes the relying party that passkeys are bound to.
+type WebAuthnConfig struct {
+	RPID      string   `yaml:"rpId"`
+	RPOrigins []string `yaml:"rpOrigins"`
+}
+
+type LogConfig struct {
+	// Level is the lowest level written: debug, info, warn or error.
+	Level string `yaml:"level"`
+
+	// Format is text or json.
+	Format string `yaml:"format"`
+}
+
+// NewLogger returns the application logger the configuration describes,
+// writing to w.
+func (c LogConfig) NewLogger(w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	if c.Format == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	}
+
+	return slog.New(slog.NewTextHandler(w, options)), nil
+}
+
+type MetricsConfig struct {
+	// Enabled exposes Prometheus metrics on /metrics.
+	Enabled bool `yaml:"enabled"`
+
+	// Address is a separate host and port to serve metrics on, so they
+	// can be kept off the public listener. When empty they are served by
+	// the main server.
+	Address string `yaml:"address"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, otlp or stdout. The stdout exporter is meant for
+	// local debugging.
+	Exporter string `yaml:"exporter"`
+
+	// Endpoint is the host and port of the OTLP/HTTP collector. When empty
+	// the standard OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string `yaml:"endpoint"`
+
+	// Insecure sends spans to the collector over plain HTTP.
+	Insecure bool `yaml:"insecure"`
+
+	// FilePath is where the stdout exporter writes spans. When empty they
+	// are written to standard output.
+	FilePath string `yaml:"filePath"`
+
+	// SampleRatio is the fraction of new traces that are recorded, from 0
+	// to 1. Requests that are part of a sampled trace are always recorded.
+	SampleRatio float64 `yaml:"sampleRatio"`
+
+	// ServiceName identifies this application in traces.
+	ServiceName string `yaml:"serviceName"`
+}
+
+// Default returns the settings used when nothing else is configured. They
+// suit local development.
+func Default() *Config {
+	lockout := domain.DefaultLockoutPolicy()
+	loginLimits := ratelimit.DefaultLoginLimits()
+
+	return &Config{
+		Environment: EnvironmentDevelopment,
+		Server: ServerConfig{
+			Address:     ":8080",
+			BaseURL:     "http://localhost:8080",
+			CORSOrigins: []string{"http://localhost:8080", "http://127.0.0.1:8080"},
+		},
+		Database: DatabaseConfig{
+			Path: "internal/data/mainframe.db",
+		},
+		Session: SessionConfig{
+			Duration: 2 * time.Hour,
+		},
+		Lockout: LockoutConfig{
+			MaxAttempts: lockout.MaxAttempts,
+			Window:      lockout.BaseWindow,
+			MaxWindow:   lockout.MaxWindow,
+		},
+		RateLimit: RateLimitConfig{
+			Store:         "memory",
+			LoginIP:       loginLimits.PerIP,
+			LoginUsername: loginLimits.PerUsername,
+			SetupIP:       loginLimits.PerIP,
+			MFAIP:         loginLimits.PerIP,
+			PasskeyIP:     loginLimits.PerIP,
+			ResetIP:       loginLimits.PerIP,
+			ResetEmail:    ratelimit.DefaultResetEmailLimit(),
+		},
+		Users: UsersConfig{
+			RetentionPeriod: 30 * 24 * time.Hour,
+		},
+		Mail: MailConfig{
+			Driver:   "log",
+			From:     "mainframe@localhost",
+			FilePath: "mail.log",
+			SMTP: SMTPConfig{
+				Port: "587",
+			},
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:      "localhost",
+			RPOrigins: []string{"http://localhost:8080"},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			SampleRatio: 1,
+			ServiceName: "mainframe",
+		},
+	}
+}
+
+// IsProduction reports whether the application is running in production.
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvironmentProduction
+}
+
+// Validate checks every setting and returns all of the problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(
+		slices.Contains([]string{EnvironmentDevelopment, EnvironmentProduction}, c.Environment),
+		"environment must be %s or %s", EnvironmentDevelopment, EnvironmentProduction,
+	)
+
+	check(c.Server.Address != "", "server.address is required")
+	check(isAbsoluteURL(c.Server.BaseURL), "server.baseUrl must be an absolute URL")
+	check(len(c.Server.CORSOrigins) > 0, "server.corsOrigins needs at least one origin")
+	for _, origin := range c.Server.CORSOrigins {
+		check(isAbsoluteURL(origin), "server.corsOrigins: %q must be an absolute URL", origin)
+	}
+
+	check(c.Server.ProxyHeader == "" || len(c.Server.TrustedProxies) > 0, "server.trustedProxies is required when server.proxyHeader is set")
+	for _, proxy := range c.Server.TrustedProxies {
+		check(isIPOrCIDR(proxy), "server.trustedProxies: %q must be an IP address or CIDR range", proxy)
+	}
+
+	check(c.Database.Path != "", "database.path is required")
+
+	check(c.Keys.ServerKey != "" || c.Keys.KeyringFile != "", "keys.serverKey or keys.keyringFile is required")
+
+	check(c.Session.Duration > 0, "session.duration must be positive")
+
+	check(c.Lockout.Window > 0, "lockout.window must be positive")
+	check(c.Lockout.MaxWindow >= c.Lockout.Window, "lockout.maxWindow must not be shorter than lockout.window")
+
+	check(slices.Contains([]string{"memory", "sqlite"}, c.RateLimit.Store), "rateLimit.store must be memory or sqlite")
+	check(c.RateLimit.LoginIP.Burst > 0 && c.RateLimit.LoginIP.Period > 0, "rateLimit.loginIp is required")
+	check(c.RateLimit.LoginUsername.Burst > 0 && c.RateLimit.LoginUsername.Period > 0, "rateLimit.loginUsername is required")
+	check(c.RateLimit.SetupIP.Burst > 0 && c.RateLimit.SetupIP.Period > 0, "rateLimit.setupIp is required")
+	check(c.RateLimit.MFAIP.Burst > 0 && c.RateLimit.MFAIP.Period > 0, "rateLimit.mfaIp is required")
+	check(c.RateLimit.PasskeyIP.Burst > 0 && c.RateLimit.PasskeyIP.Period > 0, "rateLimit.passkeyIp is required")
+	check(c.RateLimit.ResetIP.Burst > 0 && c.RateLimit.ResetIP.Period > 0, "rateLimit.resetIp is required")
+	check(c.RateLimit.ResetEmail.Burst > 0 && c.RateLimit.ResetEmail.Period > 0, "rateLimit.resetEmail is required")
+
+	check(c.Users.RetentionPeriod > 0, "users.retentionPeriod must be positive")
+
+	switch c.Mail.Driver {
+	case "log":
+	case "file":
+		check(c.Mail.FilePath != "", "mail.filePath is required by the file driver")
+	case "smtp":
+		check(c.Mail.SMTP.Host != "", "mail.smtp.host is required by the smtp driver")
+		check(c.Mail.SMTP.Port != "", "mail.smtp.port is required by the smtp driver")
+		check(c.Mail.From != "", "mail.from is required by the smtp driver")
+	default:
+		check(false, "mail.driver must be log, file or smtp")
+	}
+
+	check(c.WebAuthn.RPID != "", "webauthn.rpId is required")
+	check(len(c.WebAuthn.RPOrigins) > 0, "webauthn.rpOrigins needs at least one origin")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level must be debug, info, warn or error")
+	check(
+		slices.Contains([]string{LogFormatText, LogFormatJSON}, c.Log.Format),
+		"log.format must be %s or %s", LogFormatText, LogFormatJSON,
+	)
+
+	check(
+		c.Metrics.Address == "" || c.Metrics.Address != c.Server.Address,
+		"metrics.address must differ from server.address",
+	)
+
+	check(
+		slices.Contains([]string{TracingExporterNone, TracingExporterOTLP, TracingExporterStdout}, c.Tracing.Exporter),
+		"tracing.exporter must be %s, %s or %s", TracingExporterNone, TracingExporterOTLP, TracingExporterStdout,
+	)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sampleRatio must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "tracing.serviceName is required")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Redacted returns a copy of the configuration with secrets replaced so it
+// is safe to print or log.
+func (c *Config) Redacted() *Config {
+	copied := *c
+	copied.Server.CORSOrigins = slices.Clone(c.Server.CORSOrigins)
+	copied.Server.TrustedProxies = slices.Clone(c.Server.TrustedProxies)
+	copied.WebAuthn.RPOrigins = slices.Clone(c.WebAuthn.RPOrigins)
+
+	if copied.Keys.ServerKey != "" {
+		copied.Keys.ServerKey = redacted
+	}
+
+	if copied.Mail.SMTP.Password != "" {
+		copied.Mail.SMTP.Password = redacted
+	}
+
+	return &copied
+}
+
+// Dump returns the configuration as YAML with secrets redacted.
+func (c *Config) Dump() (string, error) {
+	var buffer bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return "", fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	return buffer.String(), nil
+}
+
+func isAbsoluteURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Scheme != "" && parsed.Host != ""
+}
+
+func isIPOrCIDR(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
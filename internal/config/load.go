@@ -0,0 +1,266 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/th3oth3rjak3/mainframe/internal/ratelimit"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the configuration file read when no other file is named.
+// It is optional.
+const DefaultFile = "mainframe.yaml"
+
+// NewFlagSet creates a flag set with the flags every command accepts for
+// overriding configuration. Commands may add their own flags before parsing.
+func NewFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.String("config", "", "path to the configuration file (default "+DefaultFile+" when it exists)")
+	flags.String("env", "", "environment, development or production")
+	flags.String("addr", "", "address the HTTP server listens on")
+	flags.String("db", "", "path to the database file")
+	flags.Bool("migrate", false, "apply pending database migrations on start")
+	return flags
+}
+
+// Load builds the configuration from the defaults, the configuration file,
+// environment variables and then any flags that were set. The flags must
+// come from NewFlagSet and already be parsed. It may be nil when there are
+// no flags. The result is not validated.
+func Load(flags *flag.FlagSet) (*Config, error) {
+	cfg := Default()
+
+	path, required := configFile(flags)
+	if err := cfg.loadFile(path, required); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadEnvironment(); err != nil {
+		return nil, err
+	}
+
+	if flags != nil {
+		if err := cfg.loadFlags(flags); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// configFile returns the file to read settings from and whether it must
+// exist. A file named by the -config flag or MAINFRAME_CONFIG must exist.
+func configFile(flags *flag.FlagSet) (string, bool) {
+	if flags != nil {
+		if value := flags.Lookup("config"); value != nil && value.Value.String() != "" {
+			return value.Value.String(), true
+		}
+	}
+
+	if path := strings.TrimSpace(os.Getenv("MAINFRAME_CONFIG")); path != "" {
+		return path, true
+	}
+
+	return DefaultFile, false
+}
+
+func (c *Config) loadFile(path string, required bool) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// environmentVariables maps each environment variable to the setting it
+// overrides.
+func (c *Config) environmentVariables() map[string]func(value string) error {
+	return map[string]func(value string) error{
+		"APP_ENV":                   setString(&c.Environment),
+		"SERVER_ADDRESS":            setString(&c.Server.Address),
+		"APP_BASE_URL":              setString(&c.Server.BaseURL),
+		"CORS_ORIGINS":              setList(&c.Server.CORSOrigins),
+		"SERVER_PROXY_HEADER":       setString(&c.Server.ProxyHeader),
+		"SERVER_TRUSTED_PROXIES":    setList(&c.Server.TrustedProxies),
+		"DB_PATH":                   setString(&c.Database.Path),
+		"DB_AUTO_MIGRATE":           setBool(&c.Database.AutoMigrate),
+		"SERVER_KEY":                setString(&c.Keys.ServerKey),
+		"SERVER_KEYRING_FILE":       setString(&c.Keys.KeyringFile),
+		"SESSION_DURATION":          setDuration(&c.Session.Duration),
+		"LOG_FORMAT":                setString(&c.Log.Format),
+		"LOG_LEVEL":                 setString(&c.Log.Level),
+		"LOCKOUT_MAX_ATTEMPTS":      setUint(&c.Lockout.MaxAttempts),
+		"LOCKOUT_WINDOW":            setDuration(&c.Lockout.Window),
+		"LOCKOUT_MAX_WINDOW":        setDuration(&c.Lockout.MaxWindow),
+		"METRICS_ADDRESS":           setString(&c.Metrics.Address),
+		"METRICS_ENABLED":           setBool(&c.Metrics.Enabled),
+		"RATE_LIMIT_STORE":          setString(&c.RateLimit.Store),
+		"RATE_LIMIT_LOGIN_IP":       setLimit(&c.RateLimit.LoginIP),
+		"RATE_LIMIT_LOGIN_USERNAME": setLimit(&c.RateLimit.LoginUsername),
+		"RATE_LIMIT_SETUP_IP":       setLimit(&c.RateLimit.SetupIP),
+		"RATE_LIMIT_MFA_IP":         setLimit(&c.RateLimit.MFAIP),
+		"RATE_LIMIT_PASSKEY_IP":     setLimit(&c.RateLimit.PasskeyIP),
+		"RATE_LIMIT_RESET_IP":       setLimit(&c.RateLimit.ResetIP),
+		"RATE_LIMIT_RESET_EMAIL":    setLimit(&c.RateLimit.ResetEmail),
+		"TRACING_ENDPOINT":          setString(&c.Tracing.Endpoint),
+		"TRACING_EXPORTER":          setString(&c.Tracing.Exporter),
+		"TRACING_FILE_PATH":         setString(&c.Tracing.FilePath),
+		"TRACING_INSECURE":          setBool(&c.Tracing.Insecure),
+		"TRACING_SAMPLE_RATIO":      setFloat(&c.Tracing.SampleRatio),
+		"TRACING_SERVICE_NAME":      setString(&c.Tracing.ServiceName),
+		"USER_RETENTION_PERIOD":     setDuration(&c.Users.RetentionPeriod),
+		"MAIL_DRIVER":               setString(&c.Mail.Driver),
+		"MAIL_FROM":                 setString(&c.Mail.From),
+		"MAIL_FILE_PATH":            setString(&c.Mail.FilePath),
+		"SMTP_HOST":                 setString(&c.Mail.SMTP.Host),
+		"SMTP_PORT":                 setString(&c.Mail.SMTP.Port),
+		"SMTP_USERNAME":             setString(&c.Mail.SMTP.Username),
+		"SMTP_PASSWORD":             setString(&c.Mail.SMTP.Password),
+		"WEBAUTHN_RP_ID":            setString(&c.WebAuthn.RPID),
+		"WEBAUTHN_RP_ORIGINS":       setList(&c.WebAuthn.RPOrigins),
+	}
+}
+
+// loadEnvironment applies every environment variable that is set and not
+// empty.
+func (c *Config) loadEnvironment() error {
+	var errs []error
+
+	variables := c.environmentVariables()
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		set := variables[name]
+		value := strings.TrimSpace(os.Getenv(name))
+		if value == "" {
+			continue
+		}
+
+		if err := set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadFlags applies the flags from NewFlagSet that were given.
+func (c *Config) loadFlags(flags *flag.FlagSet) error {
+	settings := map[string]func(value string) error{
+		"env":     setString(&c.Environment),
+		"addr":    setString(&c.Server.Address),
+		"db":      setString(&c.Database.Path),
+		"migrate": setBool(&c.Database.AutoMigrate),
+	}
+
+	var errs []error
+	flags.Visit(func(f *flag.Flag) {
+		set, ok := settings[f.Name]
+		if !ok {
+			return
+		}
+
+		if err := set(f.Value.String()); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+func setString(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+		return nil
+	}
+}
+
+// setList reads a comma separated list.
+func setList(target *[]string) func(string) error {
+	return func(value string) error {
+		items := make([]string, 0)
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		*target = items
+		return nil
+	}
+}
+
+func setBool(target *bool) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		*target = parsed
+		return nil
+	}
+}
+
+func setUint(target *uint) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return err
+		}
+
+		*target = uint(parsed)
+		return nil
+	}
+}
+
+func setFloat(target *float64) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		*target = parsed
+		return nil
+	}
+}
+
+func setDuration(target *time.Duration) func(string) error {
+	return func(value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		*target = parsed
+		return nil
+	}
+}
+
+func setLimit(target *ratelimit.Limit) func(string) error {
+	return func(value string) error {
+		return target.UnmarshalText([]byte(value))
+	}
+}
@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	// hmacKeyLabel is the HKDF info for the subkey that signs verifiers.
+	hmacKeyLabel = "mainframe/hmac-sha256/v1"
+
+	// encryptionKeyLabel is the HKDF info for the subkey that encrypts
+	// stored secrets.
+	encryptionKeyLabel = "mainframe/aes-256-gcm/v1"
+
+	// subkeyLength is the length in bytes of every derived subkey.
+	subkeyLength = 32
+)
+
+// deriveSubkey derives a 256 bit subkey for one purpose from the server key
+// with HKDF-SHA256. Different labels give independent subkeys, so the key
+// that signs verifiers is never the key that encrypts secrets.
+func deriveSubkey(key []byte, label string) ([]byte, error) {
+	subkey, err := hkdf.Key(sha256.New, key, nil, label, subkeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive %s subkey: %w", label, err)
+	}
+	return subkey, nil
+}
+
+// legacyEncryptionKey is the AES key used before subkeys were derived with
+// HKDF. It is only used to decrypt values saved by older versions.
+func legacyEncryptionKey(key []byte) []byte {
+	derived := sha256.Sum256(key)
+	return derived[:]
+}
+
+// Encrypt seals the plaintext with AES-256-GCM using the 256 bit key and
+// returns a base64.RawURLEncoding string.
+func Encrypt(plaintext []byte, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := GenerateRandomBytes(gcm.NonceSize())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt using the same key.
+func Decrypt(ciphertext string, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext encoding: %w", err)
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// newGCM returns an AES-256-GCM AEAD for the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != subkeyLength {
+		return nil, fmt.Errorf("encryption key must be %d bytes", subkeyLength)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
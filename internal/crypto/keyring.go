@@ -0,0 +1,277 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultKeyID is the key id given to a key loaded from the SERVER_KEY
+// environment variable.
+const DefaultKeyID = "default"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Keyring holds every active server key by id. New values are always
+// signed with the primary key. Older keys are kept so values signed before
+// a rotation can still be verified until the key is retired.
+type Keyring struct {
+	primaryID string
+	keys      map[string]serverKey
+}
+
+// serverKey is a server key with the subkeys derived from it.
+type serverKey struct {
+	// material is the key as configured. Verifiers signed before subkeys
+	// were derived used it directly as the HMAC key.
+	material []byte
+
+	// hmac signs and verifies verifiers.
+	hmac []byte
+
+	// encryption seals and opens stored secrets.
+	encryption []byte
+}
+
+// newServerKey derives the subkeys for the key material.
+func newServerKey(material []byte) (serverKey, error) {
+	hmacKey, err := deriveSubkey(material, hmacKeyLabel)
+	if err != nil {
+		return serverKey{}, err
+	}
+
+	encryptionKey, err := deriveSubkey(material, encryptionKeyLabel)
+	if err != nil {
+		return serverKey{}, err
+	}
+
+	return serverKey{material: material, hmac: hmacKey, encryption: encryptionKey}, nil
+}
+
+// hmacKeys returns the keys a verifier may have been signed with, newest
+// first.
+func (k serverKey) hmacKeys() [][]byte {
+	return [][]byte{k.hmac, k.material}
+}
+
+// open decrypts a value sealed with the key. The second result is true
+// when the value was sealed before subkeys were derived and should be
+// sealed again.
+func (k serverKey) open(sealed string) ([]byte, bool, error) {
+	plaintext, err := Decrypt(sealed, k.encryption)
+	if err == nil {
+		return plaintext, false, nil
+	}
+
+	plaintext, legacyErr := Decrypt(sealed, legacyEncryptionKey(k.material))
+	if legacyErr != nil {
+		return nil, false, err
+	}
+
+	return plaintext, true, nil
+}
+
+// NewKeyring creates a keyring from the keys. The primary key must be one of them.
+func NewKeyring(primaryID string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring must contain at least one key")
+	}
+
+	derived := make(map[string]serverKey, len(keys))
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+
+		if len(key) == 0 {
+			return nil, fmt.Errorf("key %q is empty", id)
+		}
+
+		derivedKey, err := newServerKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		derived[id] = derivedKey
+	}
+
+	if _, ok := keys[primaryID]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primaryID)
+	}
+
+	return &Keyring{primaryID: primaryID, keys: derived}, nil
+}
+
+// NewSingleKeyring creates a keyring that only contains one key.
+func NewSingleKeyring(key string) (*Keyring, error) {
+	return NewKeyring(DefaultKeyID, map[string][]byte{DefaultKeyID: []byte(key)})
+}
+
+// PrimaryID returns the id of the key used to sign new values.
+func (k *Keyring) PrimaryID() string {
+	return k.primaryID
+}
+
+// Sign computes the HMAC of the message with the primary key's HMAC subkey
+// and returns the primary key id with the HMAC.
+func (k *Keyring) Sign(message []byte) (keyID string, mac string) {
+	return k.primaryID, ComputeHMACSHA256(message, k.keys[k.primaryID].hmac)
+}
+
+// Encrypt seals the plaintext with the primary key's encryption subkey. The
+// key id is stored with the ciphertext so the value can be decrypted after
+// a rotation.
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	sealed, err := Encrypt(plaintext, k.keys[k.primaryID].encryption)
+	if err != nil {
+		return "", err
+	}
+
+	return k.primaryID + "." + sealed, nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values encrypted before key
+// ids were recorded are tried against every key.
+func (k *Keyring) Decrypt(ciphertext string) ([]byte, error) {
+	plaintext, _, err := k.open(ciphertext)
+	return plaintext, err
+}
+
+// Reencrypt seals a value produced by Encrypt again with the primary key.
+// The second result is false when the value already used the primary
+// key's encryption subkey and was returned unchanged.
+func (k *Keyring) Reencrypt(ciphertext string) (string, bool, error) {
+	plaintext, legacy, err := k.open(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+
+	if !legacy && EncryptionKeyID(ciphertext) == k.primaryID {
+		return ciphertext, false, nil
+	}
+
+	sealed, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+
+	return sealed, true, nil
+}
+
+// open decrypts a value produced by Encrypt. The second result is true
+// when the value was sealed with the key material itself rather than its
+// encryption subkey.
+func (k *Keyring) open(ciphertext string) ([]byte, bool, error) {
+	keyID, sealed, found := strings.Cut(ciphertext, ".")
+	if found {
+		key, ok := k.keys[keyID]
+		if !ok {
+			return nil, false, fmt.Errorf("key %q is not in the keyring", keyID)
+		}
+
+		return key.open(sealed)
+	}
+
+	for _, key := range k.keys {
+		plaintext, legacy, err := key.open(ciphertext)
+		if err == nil {
+			return plaintext, legacy, nil
+		}
+	}
+
+	return nil, false, fmt.Errorf("failed to decrypt value with any key")
+}
+
+// EncryptionKeyID returns the id of the key a value produced by Encrypt was
+// sealed with. It is empty for values encrypted before key ids were recorded.
+func EncryptionKeyID(ciphertext string) string {
+	keyID, _, found := strings.Cut(ciphertext, ".")
+	if !found {
+		return ""
+	}
+
+	return keyID
+}
+
+// hmacKeysFor returns the HMAC keys to check for a value signed with the
+// key id. An empty key id means the signing key is unknown so every key is
+// checked.
+func (k *Keyring) hmacKeysFor(keyID string) [][]byte {
+	if keyID == "" {
+		keys := make([][]byte, 0, len(k.keys)*2)
+		for _, key := range k.keys {
+			keys = append(keys, key.hmacKeys()...)
+		}
+		return keys
+	}
+
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil
+	}
+
+	return key.hmacKeys()
+}
+
+// KeyringFile is the on disk format of a keyring.
+type KeyringFile struct {
+	Primary string         `json:"primary"`
+	Keys    []KeyringEntry `json:"keys"`
+}
+
+// KeyringEntry is a single key in a keyring file.
+type KeyringEntry struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ReadKeyringFile reads a keyring file from disk.
+func ReadKeyringFile(path string) (*KeyringFile, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file KeyringFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyring file %s: %w", path, err)
+	}
+
+	return &file, nil
+}
+
+// WriteKeyringFile writes the keyring file to disk, readable only by the owner.
+func WriteKeyringFile(path string, file *KeyringFile) error {
+	contents, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(contents, '\n'), 0600)
+}
+
+// Keyring builds a keyring from the file contents.
+func (f *KeyringFile) Keyring() (*Keyring, error) {
+	keys := make(map[string][]byte, len(f.Keys))
+	for _, entry := range f.Keys {
+		if _, exists := keys[entry.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", entry.ID)
+		}
+		keys[entry.ID] = []byte(entry.Key)
+	}
+
+	return NewKeyring(f.Primary, keys)
+}
+
+// LoadKeyringFile reads a keyring file and builds the keyring.
+func LoadKeyringFile(path string) (*Keyring, error) {
+	file, err := ReadKeyringFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return file.Keyring()
+}
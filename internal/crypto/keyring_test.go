@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func newTestKeyring(t *testing.T) *Keyring {
+	t.Helper()
+
+	keyring, err := NewKeyring("new", map[string][]byte{
+		"old": []byte("old-server-key"),
+		"new": []byte("new-server-key"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	return keyring
+}
+
+func TestKeyringDerivesSeparateSubkeys(t *testing.T) {
+	keyring := newTestKeyring(t)
+	key := keyring.keys["new"]
+
+	if bytes.Equal(key.hmac, key.encryption) {
+		t.Error("the HMAC and encryption subkeys are the same")
+	}
+
+	legacy := sha256.Sum256(key.material)
+	for _, subkey := range [][]byte{key.hmac, key.encryption} {
+		if bytes.Equal(subkey, key.material) || bytes.Equal(subkey, legacy[:]) {
+			t.Error("a subkey is the key material or its SHA-256 hash")
+		}
+	}
+}
+
+func TestKeyringEncryptRoundTrips(t *testing.T) {
+	keyring := newTestKeyring(t)
+
+	ciphertext, err := keyring.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Encrypt returned an error: %v", err)
+	}
+
+	if EncryptionKeyID(ciphertext) != "new" {
+		t.Errorf("value was sealed with key %q, want new", EncryptionKeyID(ciphertext))
+	}
+
+	plaintext, err := keyring.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt returned an error: %v", err)
+	}
+
+	if string(plaintext) != "secret" {
+		t.Errorf("Decrypt = %q, want secret", plaintext)
+	}
+
+	if _, changed, err := keyring.Reencrypt(ciphertext); err != nil || changed {
+		t.Errorf("Reencrypt changed a current value: %v, %v", changed, err)
+	}
+}
+
+func TestKeyringOpensLegacyValues(t *testing.T) {
+	keyring := newTestKeyring(t)
+
+	// Values saved before subkeys were derived used the SHA-256 hash of the
+	// key material as the AES key, with and without a key id.
+	sealed, err := Encrypt([]byte("secret"), legacyEncryptionKey([]byte("new-server-key")))
+	if err != nil {
+		t.Fatalf("Encrypt returned an error: %v", err)
+	}
+
+	for _, ciphertext := range []string{"new." + sealed, sealed} {
+		plaintext, err := keyring.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt(%q) returned an error: %v", ciphertext, err)
+		}
+
+		if string(plaintext) != "secret" {
+			t.Errorf("Decrypt(%q) = %q, want secret", ciphertext, plaintext)
+		}
+
+		reencrypted, changed, err := keyring.Reencrypt(ciphertext)
+		if err != nil || !changed {
+			t.Fatalf("Reencrypt(%q) left a legacy value alone: %v, %v", ciphertext, changed, err)
+		}
+
+		if _, legacy, err := keyring.open(reencrypted); err != nil || legacy {
+			t.Errorf("re-encrypted value still needs the legacy key: %v, %v", legacy, err)
+		}
+	}
+}
+
+func TestKeyringReencryptMovesValuesToThePrimaryKey(t *testing.T) {
+	keyring := newTestKeyring(t)
+
+	sealed, err := Encrypt([]byte("secret"), keyring.keys["old"].encryption)
+	if err != nil {
+		t.Fatalf("Encrypt returned an error: %v", err)
+	}
+
+	reencrypted, changed, err := keyring.Reencrypt("old." + sealed)
+	if err != nil || !changed {
+		t.Fatalf("Reencrypt left a value on an older key: %v, %v", changed, err)
+	}
+
+	if EncryptionKeyID(reencrypted) != "new" {
+		t.Errorf("value was re-encrypted with key %q, want new", EncryptionKeyID(reencrypted))
+	}
+}
+
+func TestVerifyVerifierChecksSubkeysAndLegacyMACs(t *testing.T) {
+	keyring := newTestKeyring(t)
+	verifier := []byte("verifier")
+
+	keyID, mac := keyring.Sign(verifier)
+	if mac == ComputeHMACSHA256(verifier, []byte("new-server-key")) {
+		t.Error("Sign used the key material instead of the HMAC subkey")
+	}
+
+	if !VerifyVerifier(verifier, keyring, keyID, mac) {
+		t.Error("a verifier signed with the HMAC subkey was rejected")
+	}
+
+	legacy := ComputeHMACSHA256(verifier, []byte("new-server-key"))
+	if !VerifyVerifier(verifier, keyring, "new", legacy) {
+		t.Error("a verifier signed before subkeys were derived was rejected")
+	}
+
+	if VerifyVerifier(verifier, keyring, "old", mac) {
+		t.Error("a verifier was accepted with the wrong key id")
+	}
+
+	if VerifyVerifier([]byte("other"), keyring, keyID, mac) {
+		t.Error("a different verifier was accepted")
+	}
+}
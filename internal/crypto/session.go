@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"math/big"
 	"strings"
 )
 
@@ -43,12 +44,83 @@ func DecodeSessionToken(token string) (sessionID string, verifier []byte, err er
 }
 
 // VerifyVerifier checks if the provided token matches the stored HMAC, in constant time.
-func VerifyVerifier(token []byte, key []byte, expectedHMAC string) bool {
-	mac := hmac.New(sha256.New, key)
-	mac.Write(token)
+// The HMAC is checked with the key it was signed with. When keyID is empty every key in
+// the keyring is checked. Keys that have been retired from the keyring never match.
+// Verifiers signed with the key material itself, before HMAC subkeys were derived,
+// still match until they expire.
+func VerifyVerifier(token []byte, keyring *Keyring, keyID string, expectedHMAC string) bool {
 	expectedMAC, err := base64.RawURLEncoding.DecodeString(expectedHMAC)
 	if err != nil {
 		return false
 	}
-	return hmac.Equal(mac.Sum(nil), expectedMAC)
+
+	for _, key := range keyring.hmacKeysFor(keyID) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(token)
+		if hmac.Equal(mac.Sum(nil), expectedMAC) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// temporaryPasswordClasses are the character classes a temporary password
+// draws from. Two characters from each class are always included so the
+// result satisfies the strong password rules.
+var temporaryPasswordClasses = []string{
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"abcdefghijkmnopqrstuvwxyz",
+	"23456789",
+	"!@#$%^&*-_=+?",
+}
+
+// GenerateTemporaryPassword returns a random password of the given length
+// containing at least two characters from each character class.
+func GenerateTemporaryPassword(length int) (string, error) {
+	minimum := len(temporaryPasswordClasses) * 2
+	if length < minimum {
+		return "", fmt.Errorf("temporary password length must be at least %d", minimum)
+	}
+
+	all := strings.Join(temporaryPasswordClasses, "")
+	password := make([]byte, 0, length)
+
+	for _, class := range temporaryPasswordClasses {
+		for range 2 {
+			char, err := randomChar(class)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, char)
+		}
+	}
+
+	for len(password) < length {
+		char, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, char)
+	}
+
+	// Shuffle so the guaranteed characters aren't always at the front.
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+// randomChar returns a uniformly random character from the alphabet.
+func randomChar(alphabet string) (byte, error) {
+	idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+	if err != nil {
+		return 0, err
+	}
+	return alphabet[idx.Int64()], nil
 }
@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed from RFC 6238 Appendix B, the ASCII string
+// "12345678901234567890", encoded in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes. Codes are the HOTP value modulo a power
+	// of ten, so the 6 digit code is the last 6 digits of the 8 digit one.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, vector := range vectors {
+		code, err := GenerateTOTPCode(rfc6238Secret, time.Unix(vector.unix, 0))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode(%d) returned an error: %v", vector.unix, err)
+		}
+
+		want := vector.code[len(vector.code)-totpDigits:]
+		if code != want {
+			t.Errorf("GenerateTOTPCode(%d) = %s, want %s", vector.unix, code, want)
+		}
+	}
+}
+
+func TestGenerateTOTPCodeAcceptsLowercaseSecrets(t *testing.T) {
+	code, err := GenerateTOTPCode("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode returned an error: %v", err)
+	}
+
+	if code != "287082" {
+		t.Errorf("GenerateTOTPCode = %s, want 287082", code)
+	}
+}
+
+func TestGenerateTOTPCodeRejectsInvalidSecrets(t *testing.T) {
+	if _, err := GenerateTOTPCode("not base32!", time.Now()); err == nil {
+		t.Error("expected an error for a secret that isn't base32")
+	}
+}
+
+func TestValidateTOTPCodeAcceptsOneStepOfDrift(t *testing.T) {
+	// 1111111111 is in step 37037037, one second after it starts.
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		code, err := GenerateTOTPCode(rfc6238Secret, time.Unix((step+offset)*totpPeriod, 0))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode returned an error: %v", err)
+		}
+
+		got, valid := ValidateTOTPCode(rfc6238Secret, code, now)
+		if !valid {
+			t.Errorf("code from step offset %d was rejected", offset)
+			continue
+		}
+
+		if got != step+offset {
+			t.Errorf("code from step offset %d matched step %d, want %d", offset, got, step+offset)
+		}
+	}
+}
+
+func TestValidateTOTPCodeRejectsCodesOutsideTheWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	for _, offset := range []int64{-totpSkew - 1, totpSkew + 1} {
+		code, err := GenerateTOTPCode(rfc6238Secret, time.Unix((step+offset)*totpPeriod, 0))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode returned an error: %v", err)
+		}
+
+		if _, valid := ValidateTOTPCode(rfc6238Secret, code, now); valid {
+			t.Errorf("code from step offset %d was accepted", offset)
+		}
+	}
+}
+
+func TestValidateTOTPCodeHandlesStepBoundaries(t *testing.T) {
+	// 1111111109 is the last second of step 37037036, and 1111111110 is the
+	// first second of the next one.
+	code, err := GenerateTOTPCode(rfc6238Secret, time.Unix(1111111109, 0))
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode returned an error: %v", err)
+	}
+
+	for _, unix := range []int64{1111111109, 1111111110, 1111111139} {
+		step, valid := ValidateTOTPCode(rfc6238Secret, code, time.Unix(unix, 0))
+		if !valid {
+			t.Errorf("code was rejected at %d", unix)
+			continue
+		}
+
+		if step != 37037036 {
+			t.Errorf("code matched step %d at %d, want 37037036", step, unix)
+		}
+	}
+
+	// Two steps later the code has fallen out of the window.
+	if _, valid := ValidateTOTPCode(rfc6238Secret, code, time.Unix(1111111140, 0)); valid {
+		t.Error("code was accepted two steps after it was generated")
+	}
+}
+
+func TestValidateTOTPCodeReturnsTheSameStepForARepeatedCode(t *testing.T) {
+	// Callers refuse a step they have already claimed, so a replayed code has
+	// to map to the same step every time it is checked, even after the clock
+	// moves on to the next period.
+	code, err := GenerateTOTPCode(rfc6238Secret, time.Unix(1234567890, 0))
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode returned an error: %v", err)
+	}
+
+	first, valid := ValidateTOTPCode(rfc6238Secret, code, time.Unix(1234567890, 0))
+	if !valid {
+		t.Fatal("code was rejected")
+	}
+
+	second, valid := ValidateTOTPCode(rfc6238Secret, code, time.Unix(1234567890+totpPeriod, 0))
+	if !valid {
+		t.Fatal("code was rejected one step later")
+	}
+
+	if first != second {
+		t.Errorf("replayed code matched step %d, first use matched %d", second, first)
+	}
+}
+
+func TestValidateTOTPCodeRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870820", "94287082", "abcdef"} {
+		if _, valid := ValidateTOTPCode(rfc6238Secret, code, now); valid {
+			t.Errorf("code %q was accepted", code)
+		}
+	}
+
+	if _, valid := ValidateTOTPCode(rfc6238Secret, " 287082 ", now); !valid {
+		t.Error("code with surrounding spaces was rejected")
+	}
+
+	if _, valid := ValidateTOTPCode("not base32!", "287082", now); valid {
+		t.Error("code was accepted for an invalid secret")
+	}
+}
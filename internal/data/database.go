@@ -1,28 +1,100 @@
 package data
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/config"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
 	_ "modernc.org/sqlite"
 )
 
-// InitDB connects to the database and returns a new connection.
-func InitDB() (*sqlx.DB, error) {
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "internal/data/mainframe.db"
+// busyTimeoutMilliseconds is how long a connection waits for another
+// writer to finish before giving up with SQLITE_BUSY.
+const busyTimeoutMilliseconds = 5000
+
+// Open connects to the database at path without checking or changing its
+// schema. Every query is traced as a child of the span in its context.
+// Connections wait for a lock held by another writer instead of failing
+// straight away.
+func Open(path string) (*sqlx.DB, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
 	}
-	db, err := sqlx.Connect("sqlite", dbPath)
+
+	dsn := path + separator + "_pragma=busy_timeout(" + strconv.Itoa(busyTimeoutMilliseconds) + ")"
+
+	sqlDB, err := otelsql.Open("sqlite", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNameSQLite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	db := sqlx.NewDb(sqlDB, "sqlite")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Enable foreign keys for SQLite
 	_, err = db.Exec("PRAGMA foreign_keys = ON")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// InitDB connects to the database and returns a new connection. When
+// AutoMigrate is set any pending migrations are applied first. It refuses
+// to start against a schema newer than this binary.
+func InitDB(cfg config.DatabaseConfig, logger *slog.Logger) (*sqlx.DB, error) {
+	db, err := Open(cfg.Path)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx := context.Background()
+
+	if cfg.AutoMigrate {
+		results, err := Migrate(ctx, db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		for _, result := range results {
+			logger.Info("applied migration", "migration", result.Source.Path)
+		}
+	}
+
+	err = CheckSchemaVersion(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return db, nil
 }
+
+// Backup writes a consistent copy of the database to a new file at path.
+func Backup(db *sqlx.DB, path string) error {
+	_, err := db.Exec("VACUUM INTO ?", path)
+	if err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	return nil
+}
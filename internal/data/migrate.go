@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+	"github.com/th3oth3rjak3/mainframe/internal/data/migrations"
+)
+
+// ErrSchemaTooNew is returned when the database has migrations applied that
+// this binary doesn't know about, which means a newer version of the
+// application has upgraded it.
+var ErrSchemaTooNew = errors.New("the database schema is newer than this binary")
+
+// NewMigrator creates a goose provider that runs the embedded migrations
+// against the database.
+func NewMigrator(db *sqlx.DB) (*goose.Provider, error) {
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db.DB, migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return provider, nil
+}
+
+// Migrate applies every pending migration.
+func Migrate(ctx context.Context, db *sqlx.DB) ([]*goose.MigrationResult, error) {
+	provider, err := NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return results, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return results, nil
+}
+
+// CheckSchemaVersion makes sure the binary understands the database. It
+// returns ErrSchemaTooNew when the database is ahead of the embedded
+// migrations. A database that is behind is left for the caller to migrate.
+func CheckSchemaVersion(ctx context.Context, db *sqlx.DB) error {
+	provider, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the database schema version: %w", err)
+	}
+
+	latest := latestVersion(provider)
+	if current > latest {
+		return fmt.Errorf("%w: the database is at version %d but the newest known migration is %d", ErrSchemaTooNew, current, latest)
+	}
+
+	return nil
+}
+
+func latestVersion(provider *goose.Provider) int64 {
+	var latest int64
+	for _, source := range provider.ListSources() {
+		latest = max(latest, source.Version)
+	}
+
+	return latest
+}
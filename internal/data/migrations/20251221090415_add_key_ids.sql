@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE access_tokens ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE access_tokens DROP COLUMN key_id;
+ALTER TABLE sessions DROP COLUMN key_id;
+-- +goose StatementEnd
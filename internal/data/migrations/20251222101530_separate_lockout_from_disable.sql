@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Accounts were previously disabled automatically after five failed logins.
+-- Those lockouts are now derived from the failed attempts, so only manual
+-- disables should remain.
+UPDATE users SET is_disabled = 0 WHERE is_disabled = 1 AND failed_login_attempts >= 5;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE users SET is_disabled = 1 WHERE failed_login_attempts >= 5;
+-- +goose StatementEnd
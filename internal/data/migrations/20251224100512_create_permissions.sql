@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE permissions (
+    id TEXT PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL
+);
+
+CREATE TABLE role_permissions (
+    role_id TEXT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id TEXT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    UNIQUE(role_id, permission_id)
+);
+
+INSERT INTO permissions (id, name, description)
+VALUES
+    ('3f0b5c1e-8a6d-4f2b-9c71-2d4e6a8b0c13', 'users:read', 'View users and their sessions'),
+    ('7a2c4e6f-1b3d-4c5e-8f90-a1b2c3d4e5f6', 'users:write', 'Create, update, delete and manage users'),
+    ('b5d7f9a1-3c5e-4a7b-9d1f-2e4a6c8e0b24', 'roles:read', 'View roles and their permissions'),
+    ('c8e0a2b4-6d8f-4b1a-8c3e-5f7a9b1d3e35', 'roles:write', 'Create, update and delete roles'),
+    ('d1f3b5c7-9e1a-4d3b-a5c7-8e0b2d4f6a46', 'recipes:read', 'View recipes'),
+    ('e4a6c8e0-2b4d-4f6a-b8d0-1c3e5a7c9b57', 'recipes:write', 'Create, update and delete recipes');
+
+-- Administrators can do everything.
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT '05c9b67e-5cfa-4f01-974d-a77632637e23', id FROM permissions;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT 'ce7ed876-d7fc-41f8-a3d3-d245e6d725c8', id FROM permissions
+WHERE name IN ('recipes:read', 'recipes:write');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE roles ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE roles ADD COLUMN is_built_in INTEGER NOT NULL DEFAULT 0;
+
+UPDATE roles SET is_built_in = 1, description = 'Someone who administers the application'
+WHERE id = '05c9b67e-5cfa-4f01-974d-a77632637e23';
+
+UPDATE roles SET is_built_in = 1, description = 'All users of the application are considered basic users'
+WHERE id = '1bd84e72-796c-453c-8083-91d42465830f';
+
+UPDATE roles SET is_built_in = 1, description = 'Users who can access the recipe features'
+WHERE id = 'ce7ed876-d7fc-41f8-a3d3-d245e6d725c8';
+
+CREATE UNIQUE INDEX idx_roles_name ON roles(name COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_roles_name;
+ALTER TABLE roles DROP COLUMN is_built_in;
+ALTER TABLE roles DROP COLUMN description;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Audit events aren't tied to users with a foreign key so the history
+-- remains after a user is purged.
+CREATE TABLE audit_events (
+    id TEXT PRIMARY KEY NOT NULL,
+    actor_id TEXT,
+    actor_username TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX idx_audit_events_action ON audit_events(action);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
+
+INSERT INTO permissions (id, name, description)
+VALUES ('f7b9d1e3-5a7c-4e9b-8d2f-4a6c8e0b2d68', 'audit:read', 'View and export the audit log');
+
+INSERT INTO role_permissions (role_id, permission_id)
+VALUES ('05c9b67e-5cfa-4f01-974d-a77632637e23', 'f7b9d1e3-5a7c-4e9b-8d2f-4a6c8e0b2d68');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE id = 'f7b9d1e3-5a7c-4e9b-8d2f-4a6c8e0b2d68';
+DROP INDEX idx_audit_events_target;
+DROP INDEX idx_audit_events_actor_id;
+DROP INDEX idx_audit_events_action;
+DROP INDEX idx_audit_events_created_at;
+DROP TABLE audit_events;
+-- +goose StatementEnd
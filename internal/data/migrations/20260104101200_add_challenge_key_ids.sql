@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE mfa_challenges ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE password_reset_tokens ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE password_reset_tokens DROP COLUMN key_id;
+ALTER TABLE mfa_challenges DROP COLUMN key_id;
+-- +goose StatementEnd
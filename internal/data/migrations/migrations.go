@@ -0,0 +1,10 @@
+// Package migrations embeds the goose SQL migrations so the binary can set
+// up and upgrade its own database.
+package migrations
+
+import "embed"
+
+// FS holds every migration in this directory.
+//
+//go:embed *.sql
+var FS embed.FS
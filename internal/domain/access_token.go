@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// Access token scopes. Each scope grants a token access to one group of
+// protected routes. Routes outside of these groups can't be used with a token.
+const (
+	ScopeUsers = "users"
+	ScopeRoles = "roles"
+	ScopeAudit = "audit"
+)
+
+// AccessTokenScopes lists every scope a token can be granted.
+var AccessTokenScopes = []string{ScopeUsers, ScopeRoles, ScopeAudit}
+
+// MaxAccessTokenLifetimeDays is the longest a token can be valid for.
+const MaxAccessTokenLifetimeDays = 365
+
+// AccessToken is a personal access token used by scripts to call the API
+// with an Authorization: Bearer header. Only the HMAC of the token is stored.
+// Scopes is a comma separated list.
+type AccessToken struct {
+	ID         uuid.UUID  `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	Name       string     `db:"name"`
+	Token      string     `db:"token"`
+	KeyID      string     `db:"key_id"`
+	Scopes     string     `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+func NewAccessToken(userID uuid.UUID, name string, token string, keyID string, scopes []string, lifetime time.Duration) (*AccessToken, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	accessToken := &AccessToken{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Token:     token,
+		KeyID:     keyID,
+		Scopes:    strings.Join(scopes, ","),
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+
+	return accessToken, nil
+}
+
+// ScopeList returns the token scopes as a slice.
+func (t *AccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope returns true when the token was granted the scope.
+func (t *AccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.ScopeList(), scope)
+}
+
+// AccessTokenRead is the public view of an access token. The token is never exposed.
+type AccessTokenRead struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+func NewAccessTokenRead(token *AccessToken) AccessTokenRead {
+	return AccessTokenRead{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.ScopeList(),
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+// AccessTokenCreated is returned once when a token is created. This is the
+// only time the raw token is available.
+type AccessTokenCreated struct {
+	AccessTokenRead
+	Token string `json:"token"`
+}
+
+// AccessTokenCreateRequest creates a new personal access token.
+type AccessTokenCreateRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+func (r *AccessTokenCreateRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.Scopes, validation.Required, validation.Each(validation.In(toAny(AccessTokenScopes)...))),
+		validation.Field(&r.ExpiresInDays, validation.Required, validation.Min(1), validation.Max(MaxAccessTokenLifetimeDays)),
+	)
+}
+
+func toAny(values []string) []any {
+	result := make([]any, len(values))
+	for idx, value := range values {
+		result[idx] = value
+	}
+
+	return result
+}
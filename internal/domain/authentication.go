@@ -29,6 +29,13 @@ type LoginResponse struct {
 	LastName  string     `json:"lastName"`
 	LastLogin *time.Time `json:"lastLogin"`
 	Roles     []string   `json:"roles"`
+
+	// Permissions are every permission granted by the user's roles.
+	Permissions []string `json:"permissions"`
+
+	// MustChangePassword is true when an administrator reset the password
+	// and the user has to choose a new one before continuing.
+	MustChangePassword bool `json:"mustChangePassword"`
 }
 
 func NewLoginResponse(user *User) *LoginResponse {
@@ -44,5 +51,14 @@ func NewLoginResponse(user *User) *LoginResponse {
 		LastName:  user.LastName,
 		LastLogin: user.LastLogin,
 		Roles:     roles,
+
+		Permissions:        user.Permissions(),
+		MustChangePassword: user.MustChangePassword,
 	}
 }
+
+// CSRFTokenResponse contains the token cookie authenticated clients must
+// send in the X-CSRF-Token header on unsafe requests.
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrfToken"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "github.com/google/uuid"
+
+// StoredMFASecret is the encrypted TOTP secret saved for a user.
+type StoredMFASecret struct {
+	UserID uuid.UUID `db:"id"`
+	Secret string    `db:"mfa_secret"`
+}
+
+// KeyReferences counts the stored values that can only be checked or
+// decrypted while a server key is still in the keyring. Values saved
+// before key ids were recorded are counted against every key because the
+// key they used is unknown.
+type KeyReferences struct {
+	// MFASecrets are TOTP secrets encrypted with the key.
+	MFASecrets int `db:"mfa_secrets"`
+
+	// Sessions are unexpired sessions whose verifier was signed with the key.
+	Sessions int `db:"sessions"`
+
+	// AccessTokens are unexpired access tokens signed with the key.
+	AccessTokens int `db:"access_tokens"`
+
+	// MFAChallenges are unexpired login challenges signed with the key.
+	MFAChallenges int `db:"mfa_challenges"`
+
+	// PasswordResetTokens are unexpired password reset tokens signed with the key.
+	PasswordResetTokens int `db:"password_reset_tokens"`
+}
+
+// Total returns the number of values that still use the key.
+func (r KeyReferences) Total() int {
+	return r.MFASecrets + r.Sessions + r.AccessTokens + r.MFAChallenges + r.PasswordResetTokens
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// LockoutPolicy controls how long an account is locked after repeated
+// failed logins. Once MaxAttempts failures have been recorded the account
+// is locked for BaseWindow. Every further failure doubles the window up to
+// MaxWindow. The lock is lifted automatically when the window measured from
+// the last failed attempt has passed.
+type LockoutPolicy struct {
+	MaxAttempts uint
+	BaseWindow  time.Duration
+	MaxWindow   time.Duration
+}
+
+// DefaultLockoutPolicy locks an account for 15 minutes after 5 failures,
+// backing off to at most one day.
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		MaxAttempts: 5,
+		BaseWindow:  15 * time.Minute,
+		MaxWindow:   24 * time.Hour,
+	}
+}
+
+// Window returns how long an account with the given number of failed
+// attempts stays locked. Zero means the account is not locked.
+func (p LockoutPolicy) Window(failedAttempts uint) time.Duration {
+	if p.MaxAttempts == 0 || failedAttempts < p.MaxAttempts {
+		return 0
+	}
+
+	window := p.BaseWindow
+	for range failedAttempts - p.MaxAttempts {
+		window *= 2
+		if window >= p.MaxWindow {
+			return p.MaxWindow
+		}
+	}
+
+	return min(window, p.MaxWindow)
+}
+
+// LockedUntil returns when the user's automatic lockout ends, or nil when
+// the user is not locked out.
+func (p LockoutPolicy) LockedUntil(user *User, now time.Time) *time.Time {
+	if user.LastFailedLoginAttempt == nil {
+		return nil
+	}
+
+	window := p.Window(user.FailedLoginAttempts)
+	if window == 0 {
+		return nil
+	}
+
+	until := user.LastFailedLoginAttempt.Add(window)
+	if !until.After(now) {
+		return nil
+	}
+
+	return &until
+}
+
+// IsLocked returns true while the user is automatically locked out.
+func (p LockoutPolicy) IsLocked(user *User, now time.Time) bool {
+	return p.LockedUntil(user, now) != nil
+}
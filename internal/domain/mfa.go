@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/uuid"
+)
+
+// MFAChallenge is a pending login that has passed password verification
+// but still needs a second factor before a session is issued.
+type MFAChallenge struct {
+	ID        uuid.UUID `db:"id"`
+	Token     string    `db:"token"`
+	KeyID     string    `db:"key_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func NewMFAChallenge(userID uuid.UUID, token string, keyID string) (*MFAChallenge, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &MFAChallenge{
+		ID:        id,
+		Token:     token,
+		KeyID:     keyID,
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(time.Minute * 5),
+	}
+
+	return challenge, nil
+}
+
+// RecoveryCode is a hashed single-use code that can be used in place of a TOTP code.
+type RecoveryCode struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	CodeHash  string    `db:"code_hash"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func NewRecoveryCode(userID uuid.UUID, codeHash string) (*RecoveryCode, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	code := &RecoveryCode{
+		ID:        id,
+		UserID:    userID,
+		CodeHash:  codeHash,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	return code, nil
+}
+
+// MFARequiredResponse is returned from login when a second factor is required.
+type MFARequiredResponse struct {
+	MFARequired    bool   `json:"mfaRequired" example:"true"`
+	ChallengeToken string `json:"challengeToken"`
+}
+
+// MFALoginRequest completes a login that requires a second factor.
+// The code can either be a TOTP code or an unused recovery code.
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+}
+
+func (r *MFALoginRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.ChallengeToken, validation.Required),
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
+
+// MFAEnrollmentResponse contains the details needed to add the account to
+// an authenticator app.
+type MFAEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri" example:"otpauth://totp/Mainframe:admin?secret=..."`
+}
+
+// MFAConfirmRequest verifies that the user's authenticator app is set up correctly.
+type MFAConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *MFAConfirmRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 6), is.Digit),
+	)
+}
+
+// MFAConfirmResponse contains the recovery codes. They are only ever shown once.
+type MFAConfirmResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFADisableRequest requires both the password and a current code to turn off MFA.
+type MFADisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (r *MFADisableRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Password, validation.Required),
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
@@ -0,0 +1,23 @@
+package domain
+
+// Page is one page of a larger result set. Total is the number of items
+// across every page.
+type Page[T any] struct {
+	Items    []T `json:"items"`
+	Total    int `json:"total"`
+	Page     int `json:"page"`
+	PageSize int `json:"pageSize"`
+}
+
+func NewPage[T any](items []T, total int, page int, pageSize int) *Page[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+
+	return &Page[T]{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+}
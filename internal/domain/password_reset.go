@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/uuid"
+	v "github.com/th3oth3rjak3/mainframe/internal/validation"
+)
+
+// PasswordResetToken is a single-use token that lets a user who forgot
+// their password choose a new one. Only the HMAC of the token is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID `db:"id"`
+	Token     string    `db:"token"`
+	KeyID     string    `db:"key_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func NewPasswordResetToken(userID uuid.UUID, token string, keyID string) (*PasswordResetToken, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	resetToken := &PasswordResetToken{
+		ID:        id,
+		Token:     token,
+		KeyID:     keyID,
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(time.Minute * 30),
+	}
+
+	return resetToken, nil
+}
+
+// ForgotPasswordRequest starts the forgotten password flow.
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (r *ForgotPasswordRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Email, validation.Required, is.Email),
+	)
+}
+
+// ResetPasswordRequest sets a new password using a token from a reset email.
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Token, validation.Required),
+		validation.Field(&r.NewPassword, validation.Required, validation.Length(8, 100), v.StrongPassword()),
+	)
+}
@@ -0,0 +1,24 @@
+package domain
+
+// Permissions are granted to roles in the role_permissions table. A user
+// has every permission granted to any of their roles.
+const (
+	PermissionUsersRead    string = "users:read"    // View users and their sessions
+	PermissionUsersWrite   string = "users:write"   // Create, update, delete and manage users
+	PermissionRolesRead    string = "roles:read"    // View roles and their permissions
+	PermissionRolesWrite   string = "roles:write"   // Create, update and delete roles
+	PermissionRecipesRead  string = "recipes:read"  // View recipes
+	PermissionRecipesWrite string = "recipes:write" // Create, update and delete recipes
+	PermissionAuditRead    string = "audit:read"    // View and export the audit log
+)
+
+// Permissions is every permission that can be granted to a role.
+var Permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionRecipesRead,
+	PermissionRecipesWrite,
+	PermissionAuditRead,
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
 
 const (
 	Administrator string = "Administrator" // Someone who adminsters the application
@@ -9,6 +12,62 @@ const (
 )
 
 type Role struct {
-	ID   uuid.UUID `json:"id" db:"id"`
-	Name string    `json:"name" db:"name"`
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	IsBuiltIn   bool      `json:"isBuiltIn" db:"is_built_in"`
+	Permissions []string  `json:"permissions" db:"-"`
+}
+
+func NewRole(name string, description string, permissions []string) (*Role, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	if permissions == nil {
+		permissions = make([]string, 0)
+	}
+
+	role := &Role{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+	}
+
+	return role, nil
+}
+
+// RoleCreate is used to make a new custom role.
+type RoleCreate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r *RoleCreate) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 50)),
+		validation.Field(&r.Description, validation.Length(0, 255)),
+		validation.Field(&r.Permissions, validation.Each(validation.In(toAny(Permissions)...))),
+	)
+}
+
+// RoleUpdate replaces the name, description and permissions of a role.
+// Built-in roles can't be renamed.
+type RoleUpdate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r *RoleUpdate) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 50)),
+		validation.Field(&r.Description, validation.Length(0, 255)),
+		validation.Field(&r.Permissions, validation.Each(validation.In(toAny(Permissions)...))),
+	)
 }
@@ -7,24 +7,67 @@ import (
 )
 
 type Session struct {
-	ID        uuid.UUID `db:"id"`
-	Token     string    `db:"token"`
-	UserID    uuid.UUID `db:"user_id"`
-	ExpiresAt time.Time `db:"expires_at"`
+	ID         uuid.UUID `db:"id"`
+	Token      string    `db:"token"`
+	KeyID      string    `db:"key_id"`
+	UserID     uuid.UUID `db:"user_id"`
+	ExpiresAt  time.Time `db:"expires_at"`
+	CreatedAt  time.Time `db:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at"`
+	IPAddress  string    `db:"ip_address"`
+	UserAgent  string    `db:"user_agent"`
 }
 
-func NewSession(userID uuid.UUID, token string) (*Session, error) {
+// NewSession creates a session for the user that lasts for duration unless
+// it is used again.
+func NewSession(userID uuid.UUID, token string, keyID string, client ClientInfo, duration time.Duration) (*Session, error) {
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now().UTC()
+
 	session := &Session{
-		ID:        id,
-		Token:     token,
-		UserID:    userID,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 2),
+		ID:         id,
+		Token:      token,
+		KeyID:      keyID,
+		UserID:     userID,
+		ExpiresAt:  now.Add(duration),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
 	}
 
 	return session, nil
 }
+
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// SessionRead is the public view of a session. The token is never exposed.
+type SessionRead struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	IPAddress  string    `json:"ipAddress"`
+	UserAgent  string    `json:"userAgent"`
+	Current    bool      `json:"current"`
+}
+
+func NewSessionRead(session *Session, currentSessionID uuid.UUID) SessionRead {
+	return SessionRead{
+		ID:         session.ID,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		Current:    session.ID == currentSessionID,
+	}
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"maps"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// SetupRequest creates the first administrator. The token is the one-time
+// setup token printed when the server starts with no users.
+type SetupRequest struct {
+	Token string `json:"token"`
+	UserCreate
+}
+
+func (r *SetupRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if err := r.UserCreate.Validate(); err != nil {
+		userErrs, ok := err.(validation.Errors)
+		if !ok {
+			return err
+		}
+		maps.Copy(errs, userErrs)
+	}
+
+	if err := validation.Validate(r.Token, validation.Required); err != nil {
+		errs["token"] = err
+	}
+
+	return errs.Filter()
+}
+
+// SetupStatus tells the client whether the first administrator still needs
+// to be created.
+type SetupStatus struct {
+	Required bool `json:"required"`
+}
@@ -22,8 +22,12 @@ type User struct {
 	FailedLoginAttempts    uint       `db:"failed_login_attempts"`
 	LastFailedLoginAttempt *time.Time `db:"last_failed_login_attempt"`
 	IsDisabled             bool       `db:"is_disabled"`
+	MFASecret              *string    `db:"mfa_secret"`
+	MFAEnabled             bool       `db:"mfa_enabled"`
+	MustChangePassword     bool       `db:"must_change_password"`
 	CreatedAt              time.Time  `db:"created_at"`
 	UpdatedAt              time.Time  `db:"updated_at"`
+	DeletedAt              *time.Time `db:"deleted_at"`
 	Roles                  []Role     `db:"-"`
 }
 
@@ -61,6 +65,44 @@ func (u *User) HasRole(name string) bool {
 	})
 }
 
+// Can returns true when any of the user's roles grants the permission.
+func (u *User) Can(permission string) bool {
+	return slices.ContainsFunc(u.Roles, func(r Role) bool {
+		return slices.Contains(r.Permissions, permission)
+	})
+}
+
+// SystemUsername is the username recorded for work done from the command line.
+const SystemUsername = "system"
+
+// NewSystemActor returns the actor used by the command line. It holds every
+// permission so commands follow the same rules as the API without needing
+// a real account. It isn't stored and can never log in.
+func NewSystemActor() *User {
+	return &User{
+		ID:       uuid.Nil,
+		Username: SystemUsername,
+		Roles: []Role{
+			{Name: "System", Permissions: slices.Clone(Permissions)},
+		},
+	}
+}
+
+// Permissions returns every permission granted by the user's roles.
+func (u *User) Permissions() []string {
+	permissions := make([]string, 0)
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	slices.Sort(permissions)
+	return permissions
+}
+
 type UserRead struct {
 	ID                     uuid.UUID  `json:"id"`
 	Username               string     `json:"username"`
@@ -71,8 +113,11 @@ type UserRead struct {
 	FailedLoginAttempts    uint       `json:"failedLoginAttempts"`
 	LastFailedLoginAttempt *time.Time `json:"lastFailedLoginAttempt"`
 	IsDisabled             bool       `json:"isDisabled"`
+	MFAEnabled             bool       `json:"mfaEnabled"`
+	MustChangePassword     bool       `json:"mustChangePassword"`
 	CreatedAt              time.Time  `json:"createdAt"`
 	UpdatedAt              time.Time  `json:"updatedAt"`
+	DeletedAt              *time.Time `json:"deletedAt"`
 	Roles                  []Role     `json:"roles"`
 }
 
@@ -87,8 +132,11 @@ func NewUserRead(user *User) UserRead {
 		FailedLoginAttempts:    user.FailedLoginAttempts,
 		LastFailedLoginAttempt: user.LastFailedLoginAttempt,
 		IsDisabled:             user.IsDisabled,
+		MFAEnabled:             user.MFAEnabled,
+		MustChangePassword:     user.MustChangePassword,
 		CreatedAt:              user.CreatedAt,
 		UpdatedAt:              user.UpdatedAt,
+		DeletedAt:              user.DeletedAt,
 		Roles:                  user.Roles,
 	}
 }
@@ -128,3 +176,102 @@ func (u *UserUpdate) Validate() error {
 		validation.Field(&u.Username, validation.Required, validation.Length(3, 50)),
 	)
 }
+
+// ProfileUpdate is used by a user to change their own details. It follows
+// the same rules as UserUpdate. CurrentPassword is only needed when the
+// email address changes.
+type ProfileUpdate struct {
+	UserUpdate
+	CurrentPassword string `json:"currentPassword"`
+}
+
+func (p *ProfileUpdate) Validate() error {
+	return p.UserUpdate.Validate()
+}
+
+// PasswordChangeRequest is used by a user to change their own password.
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+func (r *PasswordChangeRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.CurrentPassword, validation.Required),
+		validation.Field(
+			&r.NewPassword,
+			validation.Required,
+			validation.Length(8, 100),
+			v.StrongPassword(),
+			validation.NotIn(r.CurrentPassword).Error("must be different from the current password"),
+		),
+	)
+}
+
+// PasswordResetResponse contains the temporary password set by an administrator.
+// The user must change it the next time they log in.
+type PasswordResetResponse struct {
+	TemporaryPassword string `json:"temporaryPassword"`
+}
+
+// UserRolesUpdate replaces every role a user has.
+type UserRolesUpdate struct {
+	RoleIDs []uuid.UUID `json:"roleIds"`
+}
+
+func (r *UserRolesUpdate) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.RoleIDs, validation.Required),
+	)
+}
+
+const (
+	DefaultUserPageSize = 25
+	MaxUserPageSize     = 100
+)
+
+// UserSortKeys are the fields users can be sorted by.
+var UserSortKeys = []string{"username", "email", "firstName", "lastName", "createdAt", "lastLogin", "deletedAt"}
+
+// UserQuery selects one page of users. Search matches any part of the
+// username, email or name. RoleID and Disabled are optional filters.
+// Deleted lists soft deleted users instead of active ones.
+type UserQuery struct {
+	Page     int    `json:"page" query:"page"`
+	PageSize int    `json:"pageSize" query:"pageSize"`
+	Search   string `json:"search" query:"search"`
+	RoleID   string `json:"roleId" query:"roleId"`
+	Disabled *bool  `json:"disabled" query:"disabled"`
+	Deleted  bool   `json:"deleted" query:"deleted"`
+	Sort     string `json:"sort" query:"sort"`
+	Order    string `json:"order" query:"order"`
+}
+
+// NewUserQuery returns a query for the first page of users sorted by username.
+func NewUserQuery() UserQuery {
+	return UserQuery{
+		Page:     1,
+		PageSize: DefaultUserPageSize,
+		Sort:     "username",
+		Order:    "asc",
+	}
+}
+
+func (q *UserQuery) Validate() error {
+	return validation.ValidateStruct(
+		q,
+		validation.Field(&q.Page, validation.Required, validation.Min(1)),
+		validation.Field(&q.PageSize, validation.Required, validation.Min(1), validation.Max(MaxUserPageSize)),
+		validation.Field(&q.Search, validation.Length(0, 100)),
+		validation.Field(&q.RoleID, is.UUID),
+		validation.Field(&q.Sort, validation.Required, validation.In(toAny(UserSortKeys)...)),
+		validation.Field(&q.Order, validation.Required, validation.In("asc", "desc")),
+	)
+}
+
+// Offset returns the number of users before the requested page.
+func (q *UserQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
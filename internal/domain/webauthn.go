@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey or security key registered to a user.
+// The full credential record is stored as JSON in Credential.
+type WebAuthnCredential struct {
+	ID           uuid.UUID  `db:"id"`
+	UserID       uuid.UUID  `db:"user_id"`
+	CredentialID string     `db:"credential_id"`
+	Name         string     `db:"name"`
+	Credential   string     `db:"credential"`
+	CreatedAt    time.Time  `db:"created_at"`
+	LastUsedAt   *time.Time `db:"last_used_at"`
+}
+
+func NewWebAuthnCredential(userID uuid.UUID, credentialID string, name string, credential string) (*WebAuthnCredential, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	newCredential := &WebAuthnCredential{
+		ID:           id,
+		UserID:       userID,
+		CredentialID: credentialID,
+		Name:         name,
+		Credential:   credential,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	return newCredential, nil
+}
+
+// WebAuthnCredentialRead is the public view of a registered authenticator.
+type WebAuthnCredentialRead struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+func NewWebAuthnCredentialRead(credential *WebAuthnCredential) WebAuthnCredentialRead {
+	return WebAuthnCredentialRead{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
+
+// WebAuthnCeremony holds the server side state of a registration or
+// assertion ceremony between the begin and finish requests. UserID is
+// nil for discoverable (username-less) logins.
+type WebAuthnCeremony struct {
+	ID          uuid.UUID  `db:"id"`
+	UserID      *uuid.UUID `db:"user_id"`
+	SessionData string     `db:"session_data"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+}
+
+func NewWebAuthnCeremony(userID *uuid.UUID, sessionData string, expiresAt time.Time) (*WebAuthnCeremony, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony := &WebAuthnCeremony{
+		ID:          id,
+		UserID:      userID,
+		SessionData: sessionData,
+		ExpiresAt:   expiresAt,
+	}
+
+	return ceremony, nil
+}
+
+// WebAuthnBeginResponse is returned when starting a ceremony. The options
+// are passed directly to navigator.credentials.create or get in the browser.
+type WebAuthnBeginResponse struct {
+	CeremonyID uuid.UUID `json:"ceremonyId"`
+	Options    any       `json:"options"`
+}
+
+// WebAuthnRegistrationFinishRequest completes registering a new authenticator.
+type WebAuthnRegistrationFinishRequest struct {
+	CeremonyID string          `json:"ceremonyId"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential" swaggertype:"object"`
+}
+
+func (r *WebAuthnRegistrationFinishRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.CeremonyID, validation.Required, is.UUID),
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.Credential, validation.Required),
+	)
+}
+
+// WebAuthnLoginFinishRequest completes a passkey login.
+type WebAuthnLoginFinishRequest struct {
+	CeremonyID string          `json:"ceremonyId"`
+	Credential json.RawMessage `json:"credential" swaggertype:"object"`
+}
+
+func (r *WebAuthnLoginFinishRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.CeremonyID, validation.Required, is.UUID),
+		validation.Field(&r.Credential, validation.Required),
+	)
+}
+
+// WebAuthnCredentialRename changes the display name of an authenticator.
+type WebAuthnCredentialRename struct {
+	Name string `json:"name"`
+}
+
+func (r *WebAuthnCredentialRename) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 100)),
+	)
+}
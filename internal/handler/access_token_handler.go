@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// HandleListAccessTokens returns the current user's personal access tokens.
+//
+// @Summary      List access tokens
+// @Description  Get every personal access token for the current user
+// @Tags         Access Tokens
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} []domain.AccessTokenRead
+// @Router       /api/auth/tokens [get]
+func HandleListAccessTokens(c *fiber.Ctx, accessTokenService services.AccessTokenService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := accessTokenService.ListTokens(c.UserContext(), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// HandleCreateAccessToken creates a personal access token for the current user.
+//
+// @Summary      Create access token
+// @Description  Create a named, scoped and expiring token for use as an Authorization: Bearer header. The token is only shown once.
+// @Tags         Access Tokens
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.AccessTokenCreateRequest true "Token details"
+// @Success      201 {object} domain.AccessTokenCreated
+// @Router       /api/auth/tokens [post]
+func HandleCreateAccessToken(c *fiber.Ctx, accessTokenService services.AccessTokenService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.AccessTokenCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	created, err := accessTokenService.CreateToken(c.UserContext(), actor, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// HandleRevokeAccessToken deletes one of the current user's personal access tokens.
+//
+// @Summary      Revoke access token
+// @Description  Delete a personal access token so it can no longer be used
+// @Tags         Access Tokens
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "Token ID"
+// @Router       /api/auth/tokens/:id [delete]
+func HandleRevokeAccessToken(c *fiber.Ctx, accessTokenService services.AccessTokenService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	tokenID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = accessTokenService.RevokeToken(c.UserContext(), actor, tokenID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
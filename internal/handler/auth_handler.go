@@ -10,7 +10,9 @@ import (
 	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
-// HandleLogin logs a user into the application.
+// HandleLogin logs a user into the application. When the user has
+// multi-factor authentication enabled, a challenge token is returned
+// instead of a session and the login must be completed at /api/auth/login/mfa.
 //
 // @Summary      Login user
 // @Description  Authenticate user credentials
@@ -19,6 +21,7 @@ import (
 // @Produce      json
 // @Param        request body domain.LoginRequest true "Login credentials"
 // @Success      200 {object} domain.LoginResponse
+// @Success      202 {object} domain.MFARequiredResponse
 // @Router       /api/auth/login [post]
 func HandleLogin(
 	c *fiber.Ctx,
@@ -35,11 +38,18 @@ func HandleLogin(
 		return err
 	}
 
-	result, err := authService.Login(&req)
+	result, err := authService.Login(c.UserContext(), &req, getClientInfo(c))
 	if err != nil {
 		return err
 	}
 
+	if result.MFARequired {
+		return c.Status(fiber.StatusAccepted).JSON(domain.MFARequiredResponse{
+			MFARequired:    true,
+			ChallengeToken: result.MFAChallengeToken,
+		})
+	}
+
 	cookieService.SetCookie(c, result.Session, result.RawSessionToken)
 
 	response := domain.NewLoginResponse(result.User)
@@ -60,12 +70,17 @@ func HandleLogout(
 	authService services.AuthenticationService,
 	cookieService services.CookieService,
 ) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	session, ok := c.Locals(mw.SessionContextKey).(*domain.Session)
 	if !ok {
 		return fmt.Errorf("could not get session from context")
 	}
 
-	if err := authService.Logout(session); err != nil {
+	if err := authService.Logout(c.UserContext(), actor, session, getClientInfo(c)); err != nil {
 		return err
 	}
 
@@ -95,3 +110,114 @@ func HandleRefreshLoginDetails(c *fiber.Ctx) error {
 	response := domain.NewLoginResponse(actor)
 	return c.JSON(response)
 }
+
+// HandleChangePassword changes the current user's password. All of the
+// user's other sessions are ended.
+//
+// @Summary      Change Password
+// @Description  Change the current user's password
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.PasswordChangeRequest true "Current and new password"
+// @Success      204
+// @Router       /api/auth/password [put]
+func HandleChangePassword(c *fiber.Ctx, authService services.AuthenticationService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	session, ok := c.Locals(mw.SessionContextKey).(*domain.Session)
+	if !ok {
+		return fmt.Errorf("could not get session from context")
+	}
+
+	var req domain.PasswordChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if err := authService.ChangePassword(c.UserContext(), actor, session, req, getClientInfo(c)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleForgotPassword emails a password reset link. The response is the
+// same whether or not the email address belongs to an account.
+//
+// @Summary      Forgot Password
+// @Description  Send a password reset link to the email address
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.ForgotPasswordRequest true "Email address"
+// @Success      202
+// @Router       /api/auth/password/forgot [post]
+func HandleForgotPassword(c *fiber.Ctx, passwordResetService services.PasswordResetService) error {
+	var req domain.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if err := passwordResetService.RequestReset(c.UserContext(), req); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// HandleResetPassword sets a new password using a token from a reset email.
+//
+// @Summary      Reset Password
+// @Description  Set a new password with a password reset token
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.ResetPasswordRequest true "Reset token and new password"
+// @Success      204
+// @Router       /api/auth/password/reset [post]
+func HandleResetPassword(c *fiber.Ctx, passwordResetService services.PasswordResetService) error {
+	var req domain.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if err := passwordResetService.ResetPassword(c.UserContext(), req); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleGetCSRFToken returns the CSRF token for the current session.
+//
+// @Summary      Get CSRF token
+// @Description  Get the token to send in the X-CSRF-Token header on POST, PUT and DELETE requests
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.CSRFTokenResponse
+// @Router       /api/auth/csrf [get]
+func HandleGetCSRFToken(c *fiber.Ctx, cookieService services.CookieService) error {
+	_, rawToken, err := cookieService.ParseSessionCookie(c.Cookies("session_id"))
+	if err != nil {
+		return shared.ErrUnauthorized
+	}
+
+	return c.JSON(domain.CSRFTokenResponse{CSRFToken: cookieService.CSRFToken(rawToken)})
+}
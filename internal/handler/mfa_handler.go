@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// HandleMFALogin completes a login that requires a second factor.
+//
+// @Summary      Complete MFA login
+// @Description  Verify a TOTP or recovery code for a pending login
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.MFALoginRequest true "Challenge token and code"
+// @Success      200 {object} domain.LoginResponse
+// @Router       /api/auth/login/mfa [post]
+func HandleMFALogin(
+	c *fiber.Ctx,
+	authService services.AuthenticationService,
+	cookieService services.CookieService,
+) error {
+	var req domain.MFALoginRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	result, err := authService.CompleteMFALogin(c.UserContext(), &req, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	cookieService.SetCookie(c, result.Session, result.RawSessionToken)
+
+	response := domain.NewLoginResponse(result.User)
+	return c.JSON(response)
+}
+
+// HandleBeginMFAEnrollment starts enrolling the current user in MFA.
+//
+// @Summary      Begin MFA enrollment
+// @Description  Generate a new TOTP secret and otpauth URI for the current user
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.MFAEnrollmentResponse
+// @Router       /api/auth/mfa/enroll [post]
+func HandleBeginMFAEnrollment(c *fiber.Ctx, mfaService services.MFAService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := mfaService.BeginEnrollment(c.UserContext(), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// HandleConfirmMFAEnrollment enables MFA for the current user.
+//
+// @Summary      Confirm MFA enrollment
+// @Description  Verify a TOTP code to enable MFA and receive recovery codes
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.MFAConfirmRequest true "TOTP code"
+// @Success      200 {object} domain.MFAConfirmResponse
+// @Router       /api/auth/mfa/confirm [post]
+func HandleConfirmMFAEnrollment(c *fiber.Ctx, mfaService services.MFAService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.MFAConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	response, err := mfaService.ConfirmEnrollment(c.UserContext(), actor, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// HandleDisableMFA turns off MFA for the current user.
+//
+// @Summary      Disable MFA
+// @Description  Disable MFA after verifying the password and a code
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.MFADisableRequest true "Password and code"
+// @Success      204
+// @Router       /api/auth/mfa/disable [post]
+func HandleDisableMFA(c *fiber.Ctx, mfaService services.MFAService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.MFADisableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	err = mfaService.Disable(c.UserContext(), actor, req)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// HandleGetProfile returns the current user's details.
+//
+// @Summary      Get Profile
+// @Description  Get the current user's details
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.UserRead
+// @Router       /api/me/profile [get]
+func HandleGetProfile(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := userService.GetProfile(c.UserContext(), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(profile)
+}
+
+// HandleUpdateProfile updates the current user's details.
+//
+// @Summary      Update Profile
+// @Description  Update the current user's name, username and email. Changing the email requires the current password.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        request body domain.ProfileUpdate true "Update Profile"
+// @Router       /api/me/profile [put]
+func HandleUpdateProfile(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request domain.ProfileUpdate
+	err = c.BodyParser(&request)
+	if err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = request.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = userService.UpdateProfile(c.UserContext(), actor, request, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
 	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
 // HandleListRoles returns a list of all roles.
@@ -20,10 +25,158 @@ func HandleListRoles(c *fiber.Ctx, roleService services.RoleService) error {
 		return err
 	}
 
-	roles, err := roleService.GetAllRoles(user)
+	roles, err := roleService.GetAllRoles(c.UserContext(), user)
 	if err != nil {
 		return err
 	}
 
 	return c.JSON(roles)
 }
+
+// HandleGetRoleByID returns a role by ID.
+//
+// @Summary      Get Role
+// @Description  Get one role by ID
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.Role
+// @Param        id path string true "Role ID"
+// @Router       /api/roles/:id [get]
+func HandleGetRoleByID(c *fiber.Ctx, roleService services.RoleService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	role, err := roleService.GetRoleByID(c.UserContext(), actor, roleID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(role)
+}
+
+// HandleCreateRole creates a new custom role.
+//
+// @Summary      Create Role
+// @Description  Create a new custom role with a set of permissions
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} map[string]string
+// @Param        request body domain.RoleCreate true "New Role"
+// @Router       /api/roles [post]
+func HandleCreateRole(c *fiber.Ctx, roleService services.RoleService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request domain.RoleCreate
+
+	err = c.BodyParser(&request)
+	if err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = request.Validate()
+	if err != nil {
+		return err
+	}
+
+	id, err := roleService.CreateRole(c.UserContext(), actor, request, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	locationUrl := fmt.Sprintf("api/roles/%s", id)
+	c.Set("Location", locationUrl)
+	return c.Status(fiber.StatusCreated).JSON(map[string]string{"id": id.String()})
+}
+
+// HandleUpdateRole renames a role and replaces its permissions.
+//
+// @Summary      Update Role
+// @Description  Update the name, description and permissions of a role
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        request body domain.RoleUpdate true "Update Role"
+// @Param        id path string true "Role ID"
+// @Router       /api/roles/:id [put]
+func HandleUpdateRole(c *fiber.Ctx, roleService services.RoleService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	var request domain.RoleUpdate
+	err = c.BodyParser(&request)
+	if err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = request.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = roleService.UpdateRole(c.UserContext(), actor, roleID, request, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleDeleteRole deletes a custom role.
+//
+// @Summary      Delete Role
+// @Description  Delete a custom role. Users who have it must be moved to another role with reassignTo.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "Role ID"
+// @Param        reassignTo query string false "Role ID to give users of the deleted role"
+// @Router       /api/roles/:id [delete]
+func HandleDeleteRole(c *fiber.Ctx, roleService services.RoleService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	var reassignTo *uuid.UUID
+	if value := c.Query("reassignTo"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return fmt.Errorf("%w: the reassignTo parameter was malformed or invalid", shared.ErrBadRequest)
+		}
+
+		reassignTo = &id
+	}
+
+	err = roleService.DeleteRole(c.UserContext(), actor, roleID, reassignTo, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
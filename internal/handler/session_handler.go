@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// HandleListOwnSessions returns the current user's active sessions.
+//
+// @Summary      List my sessions
+// @Description  Get every active session for the current user
+// @Tags         Sessions
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} []domain.SessionRead
+// @Router       /api/auth/sessions [get]
+func HandleListOwnSessions(c *fiber.Ctx, sessionService services.SessionService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := sessionService.GetOwnSessions(c.UserContext(), actor, session)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sessions)
+}
+
+// HandleRevokeOwnSession ends one of the current user's sessions.
+//
+// @Summary      Revoke my session
+// @Description  End one of the current user's sessions. Revoking the current session logs out.
+// @Tags         Sessions
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "Session ID"
+// @Router       /api/auth/sessions/:id [delete]
+func HandleRevokeOwnSession(
+	c *fiber.Ctx,
+	sessionService services.SessionService,
+	cookieService services.CookieService,
+) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	sessionID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = sessionService.RevokeOwnSession(c.UserContext(), actor, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if sessionID == session.ID {
+		cookieService.ClearCookie(c)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleRevokeOtherSessions ends every session except the current one.
+//
+// @Summary      Log out everywhere else
+// @Description  End all of the current user's sessions except the one making the request
+// @Tags         Sessions
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Router       /api/auth/sessions [delete]
+func HandleRevokeOtherSessions(c *fiber.Ctx, sessionService services.SessionService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	err = sessionService.RevokeOtherSessions(c.UserContext(), actor, session)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleListUserSessions returns the active sessions of a user.
+//
+// @Summary      List user sessions
+// @Description  Get every active session for a user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} []domain.SessionRead
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/sessions [get]
+func HandleListUserSessions(c *fiber.Ctx, sessionService services.SessionService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	sessions, err := sessionService.GetUserSessions(c.UserContext(), actor, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sessions)
+}
+
+// HandleRevokeUserSession ends one session of a user.
+//
+// @Summary      Revoke user session
+// @Description  End one of a user's sessions
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Param        sessionId path string true "Session ID"
+// @Router       /api/users/:id/sessions/:sessionId [delete]
+func HandleRevokeUserSession(c *fiber.Ctx, sessionService services.SessionService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	sessionID, err := uuid.Parse(c.Params("sessionId"))
+	if err != nil {
+		return fmt.Errorf("%w: the sessionId parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = sessionService.RevokeUserSession(c.UserContext(), actor, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleRevokeAllUserSessions ends every session of a user.
+//
+// @Summary      Revoke all user sessions
+// @Description  End every session a user has, logging them out everywhere
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/sessions [delete]
+func HandleRevokeAllUserSessions(c *fiber.Ctx, sessionService services.SessionService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = sessionService.RevokeAllUserSessions(c.UserContext(), actor, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// HandleGetSetupStatus reports whether the first administrator still needs to be created.
+//
+// @Summary      Get Setup Status
+// @Description  Check whether the first administrator still needs to be created
+// @Tags         Setup
+// @Produce      json
+// @Success      200 {object} domain.SetupStatus
+// @Router       /api/setup [get]
+func HandleGetSetupStatus(c *fiber.Ctx, setupService services.SetupService) error {
+	required, err := setupService.IsRequired(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(domain.SetupStatus{Required: required})
+}
+
+// HandleSetup creates the first administrator using the one-time setup token.
+//
+// @Summary      Create First Administrator
+// @Description  Create the first administrator with the setup token printed when the server started. Only works while no users exist.
+// @Tags         Setup
+// @Accept       json
+// @Produce      json
+// @Success      201
+// @Param        request body domain.SetupRequest true "First Administrator"
+// @Router       /api/setup [post]
+func HandleSetup(c *fiber.Ctx, setupService services.SetupService) error {
+	var request domain.SetupRequest
+
+	err := c.BodyParser(&request)
+	if err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	id, err := setupService.CreateAdministrator(c.UserContext(), request, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(map[string]string{"id": id.String()})
+}
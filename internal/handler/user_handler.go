@@ -10,14 +10,22 @@ import (
 	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
-// HandleListUsers returns a list of users.
+// HandleListUsers returns a page of users.
 //
 // @Summary      List Users
-// @Description  Get all users
+// @Description  Get a page of users, optionally filtered and sorted
 // @Tags         Users
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} []domain.UserRead
+// @Success      200 {object} domain.Page[domain.UserRead]
+// @Param        page query int false "Page number, starting at 1"
+// @Param        pageSize query int false "Users per page, at most 100"
+// @Param        search query string false "Text to find in the username, email or name"
+// @Param        roleId query string false "Only users with this role"
+// @Param        disabled query bool false "Only disabled or only enabled users"
+// @Param        deleted query bool false "List soft deleted users instead"
+// @Param        sort query string false "username, email, firstName, lastName, createdAt, lastLogin or deletedAt"
+// @Param        order query string false "asc or desc"
 // @Router       /api/users [get]
 func HandleListUsers(c *fiber.Ctx, userService services.UserService) error {
 	actor, err := getUserFromContext(c)
@@ -25,7 +33,18 @@ func HandleListUsers(c *fiber.Ctx, userService services.UserService) error {
 		return err
 	}
 
-	users, err := userService.GetAll(actor)
+	query := domain.NewUserQuery()
+	err = c.QueryParser(&query)
+	if err != nil {
+		return fmt.Errorf("%w: the query parameters are malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = query.Validate()
+	if err != nil {
+		return err
+	}
+
+	users, err := userService.GetAll(c.UserContext(), actor, query)
 	if err != nil {
 		return err
 	}
@@ -56,7 +75,7 @@ func HandleGetUserByID(c *fiber.Ctx, userService services.UserService) error {
 		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
 	}
 
-	foundUser, err := userService.GetByID(actor, userID)
+	foundUser, err := userService.GetByID(c.UserContext(), actor, userID)
 	if err != nil {
 		return err
 	}
@@ -92,7 +111,7 @@ func HandleCreateUser(c *fiber.Ctx, userService services.UserService) error {
 		return err
 	}
 
-	id, err := userService.Create(actor, request)
+	id, err := userService.Create(c.UserContext(), actor, request, getClientInfo(c))
 	if err != nil {
 		return err
 	}
@@ -137,7 +156,7 @@ func HandleUpdateUser(c *fiber.Ctx, userService services.UserService) error {
 		return err
 	}
 
-	err = userService.Update(actor, userID, request)
+	err = userService.Update(c.UserContext(), actor, userID, request, getClientInfo(c))
 	if err != nil {
 		return err
 	}
@@ -145,10 +164,10 @@ func HandleUpdateUser(c *fiber.Ctx, userService services.UserService) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// HandleDeleteUser deletes a user and all of their records
+// HandleDeleteUser soft deletes a user so they can be restored later.
 //
 // @Summary      Delete User
-// @Description  Delete an application user
+// @Description  Soft delete an application user. They can't log in until restored.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
@@ -168,10 +187,309 @@ func HandleDeleteUser(c *fiber.Ctx, userService services.UserService) error {
 		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
 	}
 
-	err = userService.Delete(actor, userID)
+	err = userService.Delete(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleRestoreUser brings back a soft deleted user.
+//
+// @Summary      Restore User
+// @Description  Restore a soft deleted application user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/restore [post]
+func HandleRestoreUser(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = userService.Restore(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandlePurgeUser permanently deletes a soft deleted user and all of their records.
+//
+// @Summary      Purge User
+// @Description  Permanently delete a soft deleted application user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/purge [delete]
+func HandlePurgeUser(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = userService.Purge(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleResetUserPassword sets a temporary password for a user
+//
+// @Summary      Reset User Password
+// @Description  Set a temporary password that must be changed at next login
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.PasswordResetResponse
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/password-reset [post]
+func HandleResetUserPassword(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	response, err := userService.ResetPassword(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// HandleUnlockUser clears an automatic lockout.
+//
+// @Summary      Unlock User
+// @Description  Clear failed login attempts so a locked out user can log in again
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/unlock [post]
+func HandleUnlockUser(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = userService.Unlock(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleDisableUser stops a user from logging in.
+//
+// @Summary      Disable User
+// @Description  Disable a user and end all of their sessions
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/disable [post]
+func HandleDisableUser(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = userService.Disable(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleEnableUser lets a disabled user log in again.
+//
+// @Summary      Enable User
+// @Description  Re-enable a user disabled by an administrator
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/enable [post]
+func HandleEnableUser(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = userService.Enable(c.UserContext(), actor, userID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleSetUserRoles replaces every role a user has.
+//
+// @Summary      Set User Roles
+// @Description  Replace all of a user's roles
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        request body domain.UserRolesUpdate true "Role IDs"
+// @Param        id path string true "User ID"
+// @Router       /api/users/:id/roles [put]
+func HandleSetUserRoles(c *fiber.Ctx, userService services.UserService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	userID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	var request domain.UserRolesUpdate
+	err = c.BodyParser(&request)
+	if err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = request.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = userService.SetRoles(c.UserContext(), actor, userID, request, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleAddUserRole grants a role to a user.
+//
+// @Summary      Add User Role
+// @Description  Grant a role to a user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Param        roleId path string true "Role ID"
+// @Router       /api/users/:id/roles/:roleId [post]
+func HandleAddUserRole(c *fiber.Ctx, userService services.UserService) error {
+	actor, userID, roleID, err := parseUserRoleParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = userService.AddRole(c.UserContext(), actor, userID, roleID, getClientInfo(c))
 	if err != nil {
 		return err
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// HandleRemoveUserRole revokes a role from a user.
+//
+// @Summary      Remove User Role
+// @Description  Revoke a role from a user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "User ID"
+// @Param        roleId path string true "Role ID"
+// @Router       /api/users/:id/roles/:roleId [delete]
+func HandleRemoveUserRole(c *fiber.Ctx, userService services.UserService) error {
+	actor, userID, roleID, err := parseUserRoleParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = userService.RemoveRole(c.UserContext(), actor, userID, roleID, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// parseUserRoleParams gets the actor and the user and role ids from the route.
+func parseUserRoleParams(c *fiber.Ctx) (*domain.User, uuid.UUID, uuid.UUID, error) {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return nil, uuid.Nil, uuid.Nil, err
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, uuid.Nil, uuid.Nil, fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	roleID, err := uuid.Parse(c.Params("roleId"))
+	if err != nil {
+		return nil, uuid.Nil, uuid.Nil, fmt.Errorf("%w: the roleId parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	return actor, userID, roleID, nil
+}
@@ -16,3 +16,19 @@ func getUserFromContext(c *fiber.Ctx) (*domain.User, error) {
 
 	return user, nil
 }
+
+func getClientInfo(c *fiber.Ctx) domain.ClientInfo {
+	return domain.ClientInfo{
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
+
+func getSessionFromContext(c *fiber.Ctx) (*domain.Session, error) {
+	session, ok := c.Locals(mw.SessionContextKey).(*domain.Session)
+	if !ok {
+		return nil, fmt.Errorf("expected session in context, but found none")
+	}
+
+	return session, nil
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/services"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// HandleBeginWebAuthnRegistration starts registering a passkey for the current user.
+//
+// @Summary      Begin passkey registration
+// @Description  Get the credential creation options for a new authenticator
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.WebAuthnBeginResponse
+// @Router       /api/auth/webauthn/register/begin [post]
+func HandleBeginWebAuthnRegistration(c *fiber.Ctx, webAuthnService services.WebAuthnService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := webAuthnService.BeginRegistration(c.UserContext(), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// HandleFinishWebAuthnRegistration saves a new passkey for the current user.
+//
+// @Summary      Finish passkey registration
+// @Description  Verify the authenticator response and save the credential
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.WebAuthnRegistrationFinishRequest true "Authenticator response"
+// @Success      200 {object} domain.WebAuthnCredentialRead
+// @Router       /api/auth/webauthn/register/finish [post]
+func HandleFinishWebAuthnRegistration(c *fiber.Ctx, webAuthnService services.WebAuthnService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.WebAuthnRegistrationFinishRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	credential, err := webAuthnService.FinishRegistration(c.UserContext(), actor, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(credential)
+}
+
+// HandleBeginWebAuthnLogin starts a passkey login.
+//
+// @Summary      Begin passkey login
+// @Description  Get the credential request options for a passkey login
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} domain.WebAuthnBeginResponse
+// @Router       /api/auth/webauthn/login/begin [post]
+func HandleBeginWebAuthnLogin(c *fiber.Ctx, webAuthnService services.WebAuthnService) error {
+	response, err := webAuthnService.BeginLogin(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// HandleFinishWebAuthnLogin logs a user in with a passkey.
+//
+// @Summary      Finish passkey login
+// @Description  Verify the passkey assertion and start a session
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Param        request body domain.WebAuthnLoginFinishRequest true "Authenticator assertion"
+// @Success      200 {object} domain.LoginResponse
+// @Router       /api/auth/webauthn/login/finish [post]
+func HandleFinishWebAuthnLogin(
+	c *fiber.Ctx,
+	authService services.AuthenticationService,
+	cookieService services.CookieService,
+) error {
+	var req domain.WebAuthnLoginFinishRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	result, err := authService.LoginWithPasskey(c.UserContext(), &req, getClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	cookieService.SetCookie(c, result.Session, result.RawSessionToken)
+
+	response := domain.NewLoginResponse(result.User)
+	return c.JSON(response)
+}
+
+// HandleListWebAuthnCredentials returns the current user's passkeys.
+//
+// @Summary      List passkeys
+// @Description  Get all authenticators registered to the current user
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} []domain.WebAuthnCredentialRead
+// @Router       /api/auth/webauthn/credentials [get]
+func HandleListWebAuthnCredentials(c *fiber.Ctx, webAuthnService services.WebAuthnService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	credentials, err := webAuthnService.ListCredentials(c.UserContext(), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(credentials)
+}
+
+// HandleRenameWebAuthnCredential renames one of the current user's passkeys.
+//
+// @Summary      Rename passkey
+// @Description  Change the display name of an authenticator
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        request body domain.WebAuthnCredentialRename true "New name"
+// @Param        id path string true "Credential ID"
+// @Router       /api/auth/webauthn/credentials/:id [put]
+func HandleRenameWebAuthnCredential(c *fiber.Ctx, webAuthnService services.WebAuthnService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	credentialID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	var request domain.WebAuthnCredentialRename
+	err = c.BodyParser(&request)
+	if err != nil {
+		return fmt.Errorf("%w: the request body is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = request.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = webAuthnService.RenameCredential(c.UserContext(), actor, credentialID, request)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleDeleteWebAuthnCredential revokes one of the current user's passkeys.
+//
+// @Summary      Revoke passkey
+// @Description  Delete an authenticator so it can no longer be used to log in
+// @Tags         WebAuthn
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Param        id path string true "Credential ID"
+// @Router       /api/auth/webauthn/credentials/:id [delete]
+func HandleDeleteWebAuthnCredential(c *fiber.Ctx, webAuthnService services.WebAuthnService) error {
+	actor, err := getUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	idString := c.Params("id")
+	credentialID, err := uuid.Parse(idString)
+
+	if err != nil {
+		return fmt.Errorf("%w: the id parameter was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	err = webAuthnService.DeleteCredential(c.UserContext(), actor, credentialID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
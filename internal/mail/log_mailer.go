@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+const developmentSender = "mainframe@localhost"
+
+type logMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer creates a mailer for development that writes each message
+// to the application log instead of delivering it.
+func NewLogMailer(logger *slog.Logger) Mailer {
+	return &logMailer{logger: logger}
+}
+
+func (m *logMailer) Send(message Message) error {
+	m.logger.Info("email", "to", message.To, "message", formatMessage(developmentSender, message))
+	return nil
+}
+
+type fileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileMailer creates a mailer for development that appends each message
+// to the file at path instead of delivering it.
+func NewFileMailer(path string) Mailer {
+	return &fileMailer{path: path}
+}
+
+func (m *fileMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\r\n\r\n", formatMessage(developmentSender, message))
+	if err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages.
+type Mailer interface {
+	// Send delivers the message or returns an error when it could not be sent.
+	Send(message Message) error
+}
+
+// formatMessage renders the message as an RFC 5322 document.
+func formatMessage(from string, message Message) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", message.To)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
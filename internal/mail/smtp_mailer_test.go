@@ -0,0 +1,268 @@
+package mail
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// smtpTransaction is what the fake server received for one message.
+type smtpTransaction struct {
+	From string
+	To   []string
+	Auth string
+	Data string
+}
+
+// fakeSMTPServer is a minimal in-process SMTP server. It doesn't offer
+// STARTTLS, so the client talks to it in plain text.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	// rejectRecipient makes the server refuse every RCPT TO command.
+	rejectRecipient bool
+
+	mu           sync.Mutex
+	transactions []smtpTransaction
+	done         sync.WaitGroup
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() {
+		listener.Close()
+		server.done.Wait()
+	})
+
+	server.done.Add(1)
+	go server.serve()
+
+	return server
+}
+
+func (s *fakeSMTPServer) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPConfig{Host: host, Port: port, From: "mainframe@example.com"}
+}
+
+func (s *fakeSMTPServer) received() []smtpTransaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]smtpTransaction(nil), s.transactions...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer s.done.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	reply := func(line string) { _ = text.PrintfLine("%s", line) }
+
+	reply("220 fake.example.com ESMTP")
+
+	var current smtpTransaction
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-fake.example.com")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			_, encoded, _ := strings.Cut(argument, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			current.Auth = string(decoded)
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			current.From = strings.Trim(strings.TrimPrefix(argument, "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			if s.rejectRecipient {
+				reply("550 5.1.1 No such user")
+				continue
+			}
+
+			current.To = append(current.To, strings.Trim(strings.TrimPrefix(argument, "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			data, err := readData(text.Reader.R)
+			if err != nil {
+				return
+			}
+
+			current.Data = data
+			s.mu.Lock()
+			s.transactions = append(s.transactions, current)
+			s.mu.Unlock()
+
+			current = smtpTransaction{}
+			reply("250 OK")
+		case "RSET":
+			current = smtpTransaction{}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// readData reads the message after DATA up to the terminating dot line,
+// keeping the CRLF line endings.
+func readData(reader *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		if line == ".\r\n" {
+			return sb.String(), nil
+		}
+
+		sb.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
+
+func TestSMTPMailerSendsEnvelopeAndBody(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.config())
+
+	err := mailer.Send(Message{
+		To:      "user@example.com",
+		Subject: "Reset your Mainframe password",
+		Body:    "Hi Test,\n\nUse the link below.\n.\nThanks",
+	})
+	if err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+
+	received := server.received()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(received))
+	}
+
+	message := received[0]
+	if message.From != "mainframe@example.com" {
+		t.Errorf("MAIL FROM was %q, want mainframe@example.com", message.From)
+	}
+
+	if len(message.To) != 1 || message.To[0] != "user@example.com" {
+		t.Errorf("RCPT TO was %v, want [user@example.com]", message.To)
+	}
+
+	if message.Auth != "" {
+		t.Errorf("expected no authentication without a username, got %q", message.Auth)
+	}
+
+	headers, body, found := strings.Cut(message.Data, "\r\n\r\n")
+	if !found {
+		t.Fatalf("message has no header separator:\n%s", message.Data)
+	}
+
+	for _, header := range []string{
+		"From: mainframe@example.com",
+		"To: user@example.com",
+		"Subject: Reset your Mainframe password",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	} {
+		if !strings.Contains(headers+"\r\n", header+"\r\n") {
+			t.Errorf("headers are missing %q:\n%s", header, headers)
+		}
+	}
+
+	// The lone dot line is escaped on the wire and restored by the server.
+	want := "Hi Test,\r\n\r\nUse the link below.\r\n.\r\nThanks\r\n"
+	if body != want {
+		t.Errorf("body was %q, want %q", body, want)
+	}
+}
+
+func TestSMTPMailerAuthenticates(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	config := server.config()
+	config.Username = "mailer"
+	config.Password = "secret"
+
+	err := NewSMTPMailer(config).Send(Message{To: "user@example.com", Subject: "Hello", Body: "Hello"})
+	if err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+
+	received := server.received()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(received))
+	}
+
+	if received[0].Auth != "\x00mailer\x00secret" {
+		t.Errorf("AUTH PLAIN credentials were %q", received[0].Auth)
+	}
+}
+
+func TestSMTPMailerReturnsServerErrors(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rejectRecipient = true
+
+	err := NewSMTPMailer(server.config()).Send(Message{To: "nobody@example.com", Subject: "Hello", Body: "Hello"})
+	if err == nil {
+		t.Fatal("expected an error when the recipient is refused")
+	}
+
+	if received := server.received(); len(received) != 0 {
+		t.Errorf("expected no messages, got %d", len(received))
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.config())
+
+	messages := []Message{
+		{To: "user@example.com\r\nBcc: attacker@example.com", Subject: "Hello", Body: "Hello"},
+		{To: "user@example.com", Subject: "Hello\r\nBcc: attacker@example.com", Body: "Hello"},
+	}
+
+	for _, message := range messages {
+		if err := mailer.Send(message); err == nil {
+			t.Errorf("expected an error for %q / %q", message.To, message.Subject)
+		}
+	}
+
+	if received := server.received(); len(received) != 0 {
+		t.Errorf("expected no messages, got %d", len(received))
+	}
+}
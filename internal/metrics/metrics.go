@@ -0,0 +1,120 @@
+// Package metrics collects the Prometheus metrics the server exposes on
+// /metrics.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mainframe"
+
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Metrics holds every collector. Each instance has its own registry so
+// building more than one, such as in the CLI, never conflicts.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// HTTPRequests counts handled requests by method, route and status.
+	HTTPRequests *prometheus.CounterVec
+
+	// HTTPRequestDuration observes request latency by method, route and status.
+	HTTPRequestDuration *prometheus.HistogramVec
+
+	// Logins counts login attempts by result, LoginSuccess or LoginFailure.
+	Logins *prometheus.CounterVec
+
+	// Lockouts counts accounts locked after too many failed logins.
+	Lockouts prometheus.Counter
+
+	// SessionsCleanedUp counts expired sessions deleted by the cleanup job.
+	SessionsCleanedUp prometheus.Counter
+}
+
+// New creates the collectors and registers them along with the Go runtime,
+// process and database connection pool collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts, by result.",
+		}, []string{"result"}),
+		Lockouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "account_lockouts_total",
+			Help:      "Accounts locked after too many failed logins.",
+		}),
+		SessionsCleanedUp: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sessions_cleaned_up_total",
+			Help:      "Expired sessions deleted by the cleanup job.",
+		}),
+	}
+
+	// Start the login results at zero so rates work before the first failure.
+	m.Logins.WithLabelValues(LoginSuccess)
+	m.Logins.WithLabelValues(LoginFailure)
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPRequestDuration,
+		m.Logins,
+		m.Lockouts,
+		m.SessionsCleanedUp,
+	)
+
+	return m
+}
+
+// RegisterDatabase exposes the connection pool statistics of db.
+func (m *Metrics) RegisterDatabase(db *sqlx.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db.DB, "sqlite"))
+}
+
+// RegisterActiveSessions exposes the number of unexpired sessions. count is
+// called on every scrape. Failures are logged and reported as zero.
+func (m *Metrics) RegisterActiveSessions(count func(ctx context.Context) (int, error), logger *slog.Logger) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Sessions that have not expired.",
+	}, func() float64 {
+		active, err := count(context.Background())
+		if err != nil {
+			logger.Error("failed to count active sessions", "error", err)
+			return 0
+		}
+
+		return float64(active)
+	}))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -21,32 +24,130 @@ const UserContextKey = contextKey("user")
 // SessionContextKey is the key used to store the session object in the request context.
 const SessionContextKey = contextKey("session")
 
-// sessionDuration defines how long a session is valid for after the last activity.
-const sessionDuration = 2 * time.Hour
+// AccessTokenContextKey is the key used to store the access token object in the request
+// context when a request was authenticated with a bearer token instead of a session.
+const AccessTokenContextKey = contextKey("accessToken")
 
 // AuthMiddleware holds the dependencies for our authentication middleware.
 type AuthMiddleware struct {
-	sessionRepo   repository.SessionRepository
-	userRepo      repository.UserRepository
-	cookieService services.CookieService
-	hmacKey       string
+	sessionRepo     repository.SessionRepository
+	userRepo        repository.UserRepository
+	accessTokenRepo repository.AccessTokenRepository
+	cookieService   services.CookieService
+	keyring         *crypto.Keyring
+
+	// sessionDuration is how long a session stays valid after the last request.
+	sessionDuration time.Duration
 }
 
 // NewAuthMiddleware creates a new instance of our AuthMiddleware.
 func NewAuthMiddleware(
 	sessionRepo repository.SessionRepository,
 	userRepo repository.UserRepository,
+	accessTokenRepo repository.AccessTokenRepository,
 	cookieService services.CookieService,
-	hmacKey string,
+	keyring *crypto.Keyring,
+	sessionDuration time.Duration,
 ) *AuthMiddleware {
 	return &AuthMiddleware{
-		sessionRepo:   sessionRepo,
-		userRepo:      userRepo,
-		cookieService: cookieService,
-		hmacKey:       hmacKey,
+		sessionRepo:     sessionRepo,
+		userRepo:        userRepo,
+		accessTokenRepo: accessTokenRepo,
+		cookieService:   cookieService,
+		keyring:         keyring,
+		sessionDuration: sessionDuration,
 	}
 }
 
+// Authenticate accepts either a personal access token sent as an
+// Authorization: Bearer header or a session cookie.
+func (m *AuthMiddleware) Authenticate(c *fiber.Ctx) error {
+	if _, ok := bearerToken(c); ok {
+		return m.TokenAuth(c)
+	}
+
+	return m.SessionAuth(c)
+}
+
+// TokenAuth authenticates a request using a personal access token.
+func (m *AuthMiddleware) TokenAuth(c *fiber.Ctx) error {
+	rawToken, ok := bearerToken(c)
+	if !ok {
+		return shared.ErrUnauthorized
+	}
+
+	tokenIDString, verifier, err := crypto.DecodeSessionToken(rawToken)
+	if err != nil {
+		return shared.ErrUnauthorized
+	}
+
+	tokenID, err := uuid.Parse(tokenIDString)
+	if err != nil {
+		return shared.ErrUnauthorized
+	}
+
+	token, err := m.accessTokenRepo.GetByID(c.UserContext(), tokenID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if token == nil || token.ExpiresAt.Before(now) {
+		return shared.ErrUnauthorized
+	}
+
+	if !crypto.VerifyVerifier(verifier, m.keyring, token.KeyID, token.Token) {
+		return shared.ErrUnauthorized
+	}
+
+	// deleted users are no longer found
+	user, err := m.userRepo.GetByID(c.UserContext(), token.UserID)
+	if errors.Is(err, shared.ErrNotFound) {
+		return shared.ErrUnauthorized
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if user == nil || user.IsDisabled {
+		return shared.ErrUnauthorized
+	}
+
+	token.LastUsedAt = &now
+	if err := m.accessTokenRepo.UpdateLastUsed(c.UserContext(), token); err != nil {
+		return err
+	}
+
+	c.Locals(UserContextKey, user)
+	c.Locals(AccessTokenContextKey, token)
+	addLogAttributes(c, "user_id", user.ID, "access_token_id", token.ID)
+
+	return c.Next()
+}
+
+// isSafeMethod returns true for methods that must not change state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// bearerToken returns the token from the Authorization header when the
+// bearer scheme is used.
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
 // SessionAuth is the actual middleware function.
 func (m *AuthMiddleware) SessionAuth(c *fiber.Ctx) error {
 	// Get the session cookie
@@ -66,47 +167,68 @@ func (m *AuthMiddleware) SessionAuth(c *fiber.Ctx) error {
 	}
 
 	// Find the session
-	session, err := m.sessionRepo.GetByID(sessionID)
+	session, err := m.sessionRepo.GetByID(c.UserContext(), sessionID)
 	if err != nil {
 		return err
 	}
 
-	// Compare the raw token with the hash value
-	valid := crypto.VerifyVerifier(rawToken, []byte(m.hmacKey), session.Token)
-	if !valid {
+	// deal with expired and not found sessions
+	if session == nil || session.ExpiresAt.Before(time.Now().UTC()) {
 		m.cookieService.ClearCookie(c)
 		return shared.ErrUnauthorized
 	}
 
-	// deal with expired and not found sessions
-	if session == nil || session.ExpiresAt.Before(time.Now().UTC()) {
+	// Compare the raw token with the hash value
+	valid := crypto.VerifyVerifier(rawToken, m.keyring, session.KeyID, session.Token)
+	if !valid {
 		m.cookieService.ClearCookie(c)
 		return shared.ErrUnauthorized
 	}
 
-	// Update sliding expiration window
-	newExpiration := time.Now().UTC().Add(sessionDuration)
-	session.ExpiresAt = newExpiration
-	if err := m.sessionRepo.Update(session); err != nil {
-		return err
+	// Cookies are sent by the browser on cross site requests, so unsafe
+	// requests must also prove they can read the CSRF token.
+	if !isSafeMethod(c.Method()) {
+		expected := m.cookieService.CSRFToken(rawToken)
+		provided := c.Get(services.CSRFHeaderName)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) != 1 {
+			Logger(c).Warn("csrf token missing or invalid", "path", c.OriginalURL())
+			return fmt.Errorf("%w: missing or invalid CSRF token", shared.ErrForbidden)
+		}
 	}
 
-	// Create and set a new cookie with the updated expiration.
-	m.cookieService.SetCookie(c, session, rawToken)
+	// Fetch the user associated with the valid session before extending
+	// it so disabled and deleted users can't keep their sessions alive.
+	// deleted users are no longer found
+	user, err := m.userRepo.GetByID(c.UserContext(), session.UserID)
+	if errors.Is(err, shared.ErrNotFound) {
+		m.cookieService.ClearCookie(c)
+		return shared.ErrUnauthorized
+	}
 
-	// Fetch the user associated with the valid session.
-	user, err := m.userRepo.GetByID(session.UserID)
 	if err != nil {
 		return err
 	}
 
-	if user == nil {
+	if user == nil || user.IsDisabled {
+		m.cookieService.ClearCookie(c)
 		return shared.ErrUnauthorized
 	}
 
+	// Update sliding expiration window
+	now := time.Now().UTC()
+	session.ExpiresAt = now.Add(m.sessionDuration)
+	session.LastSeenAt = now
+	if err := m.sessionRepo.Update(c.UserContext(), session); err != nil {
+		return err
+	}
+
+	// Create and set a new cookie with the updated expiration.
+	m.cookieService.SetCookie(c, session, rawToken)
+
 	// Attach the user object to the context for downstream handlers.
 	c.Locals(UserContextKey, user)
 	c.Locals(SessionContextKey, session)
+	addLogAttributes(c, "user_id", user.ID)
 
 	// Proceed to the next handler in the chain.
 	return c.Next()
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/metrics"
+)
+
+// Metrics counts and times every request. Requests are labelled with the
+// route pattern rather than the path so ids don't create new series. It
+// must run before RequestLogger so the status has been set by the error
+// handler.
+func Metrics(m *metrics.Metrics) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		method := c.Method()
+		route := c.Route().Path
+		status := strconv.Itoa(c.Response().StatusCode())
+
+		m.HTTPRequests.WithLabelValues(method, route, status).Inc()
+		m.HTTPRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request id. It is echoed in every response so
+// a report from a client can be matched to the server logs.
+const RequestIDHeader = fiber.HeaderXRequestID
+
+// RequestIDContextKey is the key used to store the request id in the request context.
+const RequestIDContextKey = contextKey("requestID")
+
+// LoggerContextKey is the key used to store the request scoped logger in the request context.
+const LoggerContextKey = contextKey("logger")
+
+// maxRequestIDLength bounds request ids supplied by clients.
+const maxRequestIDLength = 64
+
+// RequestID assigns every request an id. An id sent by the client, such as
+// one set by a proxy, is kept when it is short and only uses safe
+// characters. Otherwise a new one is generated.
+func RequestID(c *fiber.Ctx) error {
+	requestID := c.Get(RequestIDHeader)
+	if !isValidRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+
+	c.Set(RequestIDHeader, requestID)
+	c.Locals(RequestIDContextKey, requestID)
+
+	return c.Next()
+}
+
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		isAlphanumeric := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlphanumeric && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RequestLogger gives each request a logger tagged with its request id,
+// and trace id when the request is traced, and logs every request once it
+// has been handled. It must run after RequestID and Tracing.
+func RequestLogger(logger *slog.Logger) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID, _ := c.Locals(RequestIDContextKey).(string)
+		requestLogger := logger.With("request_id", requestID)
+
+		if spanContext := trace.SpanContextFromContext(c.UserContext()); spanContext.IsSampled() {
+			requestLogger = requestLogger.With("trace_id", spanContext.TraceID().String())
+		}
+
+		c.Locals(LoggerContextKey, requestLogger)
+
+		// Errors are handled here rather than by the router so the logged
+		// status is the one the client receives.
+		err := c.Next()
+		if err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		attributes := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"latency", time.Since(start),
+			"ip", c.IP(),
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+			attributes = append(attributes, "error", err)
+		}
+
+		Logger(c).Log(c.UserContext(), level, "request", attributes...)
+
+		return nil
+	}
+}
+
+// Logger returns the logger for the request. Outside of RequestLogger it
+// falls back to the default logger.
+func Logger(c *fiber.Ctx) *slog.Logger {
+	if logger, ok := c.Locals(LoggerContextKey).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// addLogAttributes adds attributes to every later log line of the request.
+func addLogAttributes(c *fiber.Ctx, args ...any) {
+	c.Locals(LoggerContextKey, Logger(c).With(args...))
+}
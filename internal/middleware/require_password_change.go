@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// BlockPendingPasswordChange rejects requests from users whose password was
+// reset by an administrator until they choose a new password.
+func BlockPendingPasswordChange(c *fiber.Ctx) error {
+	user, ok := c.Locals(UserContextKey).(*domain.User)
+	if !ok || user == nil {
+		return fmt.Errorf("user expected in context but was not found")
+	}
+
+	if user.MustChangePassword {
+		return fmt.Errorf("%w: the password must be changed before continuing", shared.ErrForbidden)
+	}
+
+	return c.Next()
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// RequireScope rejects requests authenticated with a personal access token
+// that was not granted the scope. Session authenticated requests are not
+// restricted by scopes.
+func RequireScope(scope string) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		token, ok := c.Locals(AccessTokenContextKey).(*domain.AccessToken)
+		if !ok || token == nil {
+			return c.Next()
+		}
+
+		if !token.HasScope(scope) {
+			Logger(c).Warn("access token missing scope", "scope", scope, "path", c.OriginalURL())
+			return shared.ErrForbidden
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket that holds up to Burst tokens and refills the
+// whole bucket evenly over Period. Each request takes one token.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit parses a limit written as "<burst>/<period>", for example "10/1m".
+func ParseLimit(value string) (Limit, error) {
+	burstString, periodString, found := strings.Cut(strings.TrimSpace(value), "/")
+	if !found {
+		return Limit{}, fmt.Errorf("limit %q must be in the form <burst>/<period>", value)
+	}
+
+	burst, err := strconv.Atoi(burstString)
+	if err != nil || burst < 1 {
+		return Limit{}, fmt.Errorf("limit %q has an invalid burst", value)
+	}
+
+	period, err := time.ParseDuration(periodString)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("limit %q has an invalid period", value)
+	}
+
+	return Limit{Burst: burst, Period: period}, nil
+}
+
+// String returns the limit in the form ParseLimit reads.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// MarshalText writes the limit in the form ParseLimit reads so it can be
+// used in configuration files.
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText reads a limit written as "<burst>/<period>".
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+
+	*l = limit
+	return nil
+}
+
+// refillRate returns the number of tokens added per second.
+func (l Limit) refillRate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Bucket is the saved state of a token bucket.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// take refills the bucket for the time since it was last updated and then
+// tries to take one token. When no token is available it returns how long
+// until one will be.
+func (b Bucket) take(limit Limit, now time.Time) (Bucket, bool, time.Duration) {
+	tokens := float64(limit.Burst)
+	if !b.UpdatedAt.IsZero() {
+		elapsed := max(now.Sub(b.UpdatedAt).Seconds(), 0)
+		tokens = math.Min(float64(limit.Burst), b.Tokens+elapsed*limit.refillRate())
+	}
+
+	if tokens >= 1 {
+		return Bucket{Tokens: tokens - 1, UpdatedAt: now}, true, 0
+	}
+
+	wait := time.Duration((1 - tokens) / limit.refillRate() * float64(time.Second))
+	return Bucket{Tokens: tokens, UpdatedAt: now}, false, wait
+}
+
+// Store keeps token buckets by key.
+type Store interface {
+	// Take tries to take a token from the bucket for the key. When the
+	// bucket is empty allowed is false and retryAfter is how long until
+	// a token is available.
+	Take(key string, limit Limit, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// Limiter applies one limit to keys in a store.
+type Limiter struct {
+	store  Store
+	limit  Limit
+	prefix string
+}
+
+// NewLimiter creates a limiter. The name is used to keep keys from
+// different limiters apart when they share a store.
+func NewLimiter(store Store, name string, limit Limit) *Limiter {
+	return &Limiter{store: store, limit: limit, prefix: name + ":"}
+}
+
+// Allow takes a token for the key.
+func (l *Limiter) Allow(key string) (bool, time.Duration, error) {
+	return l.store.Take(l.prefix+key, l.limit, time.Now().UTC())
+}
+
+// LoginLimits are the limits applied to login attempts.
+type LoginLimits struct {
+	PerIP       Limit
+	PerUsername Limit
+}
+
+// DefaultResetEmailLimit allows 3 reset emails every 15 minutes
+// for one address.
+func DefaultResetEmailLimit() Limit {
+	return Limit{Burst: 3, Period: 15 * time.Minute}
+}
+
+// DefaultLoginLimits allows 20 attempts a minute from one IP address and
+// 5 attempts a minute for one username.
+func DefaultLoginLimits() LoginLimits {
+	return LoginLimits{
+		PerIP:       Limit{Burst: 20, Period: time.Minute},
+		PerUsername: Limit{Burst: 5, Period: time.Minute},
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often full buckets are removed from memory.
+const pruneInterval = 10 * time.Minute
+
+type memoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]memoryBucket
+	lastPruned time.Time
+}
+
+// memoryBucket remembers the refill period of the limit that owns the
+// bucket so it can be pruned once it would be full again.
+type memoryBucket struct {
+	Bucket
+	period time.Duration
+}
+
+// NewMemoryStore creates a store that keeps buckets in memory. Limits are
+// reset when the application restarts.
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]memoryBucket)}
+}
+
+func (s *memoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	bucket, allowed, retryAfter := s.buckets[key].take(limit, now)
+	s.buckets[key] = memoryBucket{Bucket: bucket, period: limit.Period}
+
+	return allowed, retryAfter, nil
+}
+
+// prune removes buckets that haven't been used for longer than a full
+// refill so the map doesn't grow forever. A removed bucket is the same as
+// a full one.
+func (s *memoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPruned) < pruneInterval {
+		return
+	}
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.UpdatedAt) > bucket.period {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastPruned = now
+}
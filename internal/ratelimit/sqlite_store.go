@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type sqliteStore struct {
+	db *sqlx.DB
+	mu sync.Mutex
+}
+
+// NewSQLiteStore creates a store that keeps buckets in the database so
+// limits survive restarts. Buckets are removed by the cleanup job once
+// they would be full again.
+func NewSQLiteStore(db *sqlx.DB) Store {
+	return &sqliteStore{db: db}
+}
+
+func (s *sqliteStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	// SQLite only allows one writer, so serialize here instead of
+	// retrying busy transactions.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to begin rate limit transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var bucket Bucket
+	query := "SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = ?"
+	err = tx.QueryRowx(query, key).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return false, 0, fmt.Errorf("failed to get rate limit bucket: %w", err)
+	}
+
+	bucket, allowed, retryAfter := bucket.take(limit, now)
+
+	upsert := `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = excluded.tokens,
+			updated_at = excluded.updated_at,
+			expires_at = excluded.expires_at
+	`
+
+	_, err = tx.Exec(upsert, key, bucket.Tokens, bucket.UpdatedAt, bucket.UpdatedAt.Add(limit.Period))
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to save rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, 0, fmt.Errorf("failed to commit rate limit bucket: %w", err)
+	}
+
+	return allowed, retryAfter, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+type AccessTokenRepository interface {
+	// GetByID gets an access token by its id. If a token is not found
+	// then the returned token will be nil and no error will be returned.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.AccessToken, error)
+
+	// GetByUserID returns all of the user's tokens, newest first.
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.AccessToken, error)
+
+	// Create saves a new access token.
+	Create(ctx context.Context, token *domain.AccessToken) error
+
+	// UpdateLastUsed records when the token was last used.
+	UpdateLastUsed(ctx context.Context, token *domain.AccessToken) error
+
+	// DeleteByID deletes the access token with the given id.
+	DeleteByID(ctx context.Context, id uuid.UUID) error
+
+	// DeleteByUserID deletes every access token that belongs to the user.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+type sqliteAccessTokenRepository struct {
+	db *sqlx.DB
+}
+
+// NewAccessTokenRepository creates a new personal access token repository.
+func NewAccessTokenRepository(db *sqlx.DB) AccessTokenRepository {
+	return &sqliteAccessTokenRepository{db: db}
+}
+
+func (r *sqliteAccessTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AccessToken, error) {
+	var token domain.AccessToken
+
+	query := `
+		SELECT id, user_id, name, token, key_id, scopes, created_at, expires_at, last_used_at
+		FROM access_tokens
+		WHERE id = ?`
+
+	err := r.db.GetContext(ctx, &token, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("access token repository get by id error: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *sqliteAccessTokenRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.AccessToken, error) {
+	tokens := make([]domain.AccessToken, 0)
+
+	query := `
+		SELECT id, user_id, name, token, key_id, scopes, created_at, expires_at, last_used_at
+		FROM access_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &tokens, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *sqliteAccessTokenRepository) Create(ctx context.Context, token *domain.AccessToken) error {
+	query := `
+		INSERT INTO access_tokens (id, user_id, name, token, key_id, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.Token,
+		token.KeyID,
+		token.Scopes,
+		token.CreatedAt,
+		token.ExpiresAt)
+
+	if err != nil {
+		return fmt.Errorf("create access token repository error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected err: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to create 1 access token, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteAccessTokenRepository) UpdateLastUsed(ctx context.Context, token *domain.AccessToken) error {
+	query := "UPDATE access_tokens SET last_used_at = ? WHERE id = ?"
+
+	result, err := r.db.ExecContext(ctx, query, token.LastUsedAt, token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update access token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo update rows affected error: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to update 1 access token, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteAccessTokenRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM access_tokens WHERE id = ?"
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to delete 1 record, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteAccessTokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := "DELETE FROM access_tokens WHERE user_id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access tokens by user id: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+type AuditRepository interface {
+	// Create saves a new audit event.
+	Create(ctx context.Context, event *domain.AuditEvent) error
+
+	// Search returns one page of the events matching the query, newest
+	// first, along with the number of events that match across every page.
+	Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, int, error)
+
+	// Export returns up to limit events matching the query, newest first.
+	// Paging in the query is ignored.
+	Export(ctx context.Context, query domain.AuditQuery, limit int) ([]domain.AuditEvent, error)
+}
+
+type sqliteAuditRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuditRepository creates a new audit event repository.
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &sqliteAuditRepository{db: db}
+}
+
+const auditEventColumns = `
+	id, actor_id, actor_username, action, target_type, target_id,
+	ip_address, user_agent, details, created_at
+`
+
+func (r *sqliteAuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (` + auditEventColumns + `)
+		VALUES (
+			:id, :actor_id, :actor_username, :action, :target_type, :target_id,
+			:ip_address, :user_agent, :details, :created_at
+		)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, event)
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteAuditRepository) Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, int, error) {
+	where, args := auditConditions(query)
+
+	var total int
+	err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM audit_events "+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	events, err := r.selectEvents(ctx, where, args, query.PageSize, query.Offset())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+func (r *sqliteAuditRepository) Export(ctx context.Context, query domain.AuditQuery, limit int) ([]domain.AuditEvent, error) {
+	where, args := auditConditions(query)
+	return r.selectEvents(ctx, where, args, limit, 0)
+}
+
+func (r *sqliteAuditRepository) selectEvents(ctx context.Context, where string, args []any, limit int, offset int) ([]domain.AuditEvent, error) {
+	query := `
+		SELECT ` + auditEventColumns + `
+		FROM audit_events
+		` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT ? OFFSET ?
+	`
+
+	events := make([]domain.AuditEvent, 0)
+	err := r.db.SelectContext(ctx, &events, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+// auditConditions builds the WHERE clause for the filters in the query.
+func auditConditions(query domain.AuditQuery) (string, []any) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	if query.Action != "" {
+		if strings.HasSuffix(query.Action, ".") {
+			conditions = append(conditions, `action LIKE ? ESCAPE '\'`)
+			args = append(args, escapeLike(query.Action)+"%")
+		} else {
+			conditions = append(conditions, "action = ?")
+			args = append(args, query.Action)
+		}
+	}
+
+	if query.ActorID != "" {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, query.ActorID)
+	}
+
+	if query.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, query.TargetType)
+	}
+
+	if query.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, query.TargetID)
+	}
+
+	if from := query.FromTime(); from != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *from)
+	}
+
+	if to := query.ToTime(); to != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *to)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
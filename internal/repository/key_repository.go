@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+type KeyRepository interface {
+	// GetMFASecrets returns every stored TOTP secret, including those of
+	// soft deleted users and enrollments that were never confirmed.
+	GetMFASecrets(ctx context.Context) ([]domain.StoredMFASecret, error)
+
+	// UpdateMFASecret replaces the user's stored TOTP secret.
+	UpdateMFASecret(ctx context.Context, userID uuid.UUID, secret string) error
+
+	// CountReferences counts the stored values that still depend on the key.
+	CountReferences(ctx context.Context, keyID string) (*domain.KeyReferences, error)
+}
+
+type sqliteKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewKeyRepository creates a repository for the values that depend on the server keys.
+func NewKeyRepository(db *sqlx.DB) KeyRepository {
+	return &sqliteKeyRepository{db: db}
+}
+
+func (r *sqliteKeyRepository) GetMFASecrets(ctx context.Context) ([]domain.StoredMFASecret, error) {
+	secrets := make([]domain.StoredMFASecret, 0)
+
+	err := r.db.SelectContext(ctx, &secrets, "SELECT id, mfa_secret FROM users WHERE mfa_secret IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa secrets: %w", err)
+	}
+
+	return secrets, nil
+}
+
+func (r *sqliteKeyRepository) UpdateMFASecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET mfa_secret = ? WHERE id = ?", secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update mfa secret: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows != 1 {
+		return fmt.Errorf("expected to update 1 user row, but rows affected was %d", rows)
+	}
+
+	return nil
+}
+
+func (r *sqliteKeyRepository) CountReferences(ctx context.Context, keyID string) (*domain.KeyReferences, error) {
+	var references domain.KeyReferences
+
+	// Secrets encrypted before key ids were recorded have no "id." prefix.
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users
+				WHERE mfa_secret LIKE ? ESCAPE '\' OR instr(mfa_secret, '.') = 0) AS mfa_secrets,
+			(SELECT COUNT(*) FROM sessions
+				WHERE key_id IN (?, '') AND expires_at > ?) AS sessions,
+			(SELECT COUNT(*) FROM access_tokens
+				WHERE key_id IN (?, '') AND expires_at > ?) AS access_tokens,
+			(SELECT COUNT(*) FROM mfa_challenges
+				WHERE key_id IN (?, '') AND expires_at > ?) AS mfa_challenges,
+			(SELECT COUNT(*) FROM password_reset_tokens
+				WHERE key_id IN (?, '') AND expires_at > ?) AS password_reset_tokens
+	`
+
+	now := time.Now().UTC()
+
+	err := r.db.GetContext(ctx, &references, query, escapeLike(keyID)+".%", keyID, now, keyID, now, keyID, now, keyID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count key references: %w", err)
+	}
+
+	return &references, nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+type MFARepository interface {
+	// GetChallengeByID gets a pending login challenge by its id. If a challenge
+	// is not found then the returned challenge will be nil and no error will be returned.
+	GetChallengeByID(ctx context.Context, id uuid.UUID) (*domain.MFAChallenge, error)
+
+	// CreateChallenge saves a new pending login challenge.
+	CreateChallenge(ctx context.Context, challenge *domain.MFAChallenge) error
+
+	// DeleteChallengeByID deletes the challenge with the given id.
+	DeleteChallengeByID(ctx context.Context, id uuid.UUID) error
+
+	// GetRecoveryCodes returns all of the unused recovery codes for the user.
+	GetRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]domain.RecoveryCode, error)
+
+	// ReplaceRecoveryCodes removes any existing recovery codes for the user
+	// and saves the new ones in a single transaction.
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []domain.RecoveryCode) error
+
+	// DeleteRecoveryCodeByID deletes a recovery code once it has been used.
+	DeleteRecoveryCodeByID(ctx context.Context, id uuid.UUID) error
+
+	// DeleteRecoveryCodes removes all recovery codes for the user.
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+
+	// ClaimTOTPStep records the TOTP time step as the last one the user
+	// used. It returns false without changing anything when the step is not
+	// later than the last one, which means the code was already used.
+	ClaimTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+}
+
+type sqliteMFARepository struct {
+	db *sqlx.DB
+}
+
+// NewMFARepository creates a new multi-factor authentication repository.
+func NewMFARepository(db *sqlx.DB) MFARepository {
+	return &sqliteMFARepository{db: db}
+}
+
+func (r *sqliteMFARepository) GetChallengeByID(ctx context.Context, id uuid.UUID) (*domain.MFAChallenge, error) {
+	var challenge domain.MFAChallenge
+
+	query := `
+		SELECT id, token, key_id, user_id, expires_at
+		FROM mfa_challenges
+		WHERE id = ?`
+
+	err := r.db.GetContext(ctx, &challenge, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("mfa repository get challenge by id error: %w", err)
+	}
+
+	return &challenge, nil
+}
+
+func (r *sqliteMFARepository) CreateChallenge(ctx context.Context, challenge *domain.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (id, token, key_id, user_id, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, challenge.ID, challenge.Token, challenge.KeyID, challenge.UserID, challenge.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create mfa challenge repository error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected err: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to create 1 mfa challenge, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteMFARepository) DeleteChallengeByID(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM mfa_challenges WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete mfa challenge by id error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteMFARepository) GetRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]domain.RecoveryCode, error) {
+	codes := make([]domain.RecoveryCode, 0)
+
+	query := `
+		SELECT id, user_id, code_hash, created_at
+		FROM mfa_recovery_codes
+		WHERE user_id = ?
+	`
+
+	err := r.db.SelectContext(ctx, &codes, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (r *sqliteMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []domain.RecoveryCode) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete existing recovery codes: %w", err)
+	}
+
+	for _, code := range codes {
+		query := `
+			INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES (?, ?, ?, ?)
+		`
+
+		result, err := tx.ExecContext(ctx, query, code.ID, code.UserID, code.CodeHash, code.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return fmt.Errorf("expected to create 1 recovery code, but affected was %d", affected)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteMFARepository) DeleteRecoveryCodeByID(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM mfa_recovery_codes WHERE id = ?"
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to delete 1 recovery code, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteMFARepository) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	query := "DELETE FROM mfa_recovery_codes WHERE user_id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteMFARepository) ClaimTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	// The comparison and the update happen in one statement so two requests
+	// racing with the same code can't both claim the step.
+	query := "UPDATE users SET mfa_last_step = ? WHERE id = ? AND mfa_last_step < ?"
+
+	result, err := r.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim totp step: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return affected == 1, nil
+}
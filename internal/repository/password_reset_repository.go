@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+)
+
+type PasswordResetRepository interface {
+	// GetByID gets a reset token by its id. If a token is not found
+	// then the returned token will be nil and no error will be returned.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.PasswordResetToken, error)
+
+	// Create saves a new reset token.
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+
+	// DeleteByUserID deletes every reset token that belongs to the user.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+type sqlitePasswordResetRepository struct {
+	db *sqlx.DB
+}
+
+// NewPasswordResetRepository creates a new password reset token repository.
+func NewPasswordResetRepository(db *sqlx.DB) PasswordResetRepository {
+	return &sqlitePasswordResetRepository{db: db}
+}
+
+func (r *sqlitePasswordResetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+
+	query := `
+		SELECT id, token, key_id, user_id, expires_at
+		FROM password_reset_tokens
+		WHERE id = ?`
+
+	err := r.db.GetContext(ctx, &token, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("password reset repository get by id error: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *sqlitePasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, token, key_id, user_id, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, token.ID, token.Token, token.KeyID, token.UserID, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create password reset token repository error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected err: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to create 1 password reset token, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqlitePasswordResetRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := "DELETE FROM password_reset_tokens WHERE user_id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("delete password reset tokens by user id error: %w", err)
+	}
+
+	return nil
+}
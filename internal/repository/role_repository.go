@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
 	"github.com/th3oth3rjak3/mainframe/internal/shared"
@@ -12,11 +14,29 @@ import (
 
 type RoleRepository interface {
 	// GetAll returns all roles or an error.
-	GetAll() ([]domain.Role, error)
+	GetAll(ctx context.Context) ([]domain.Role, error)
 
-	// GetByName searches for a role by its name. If not found,
-	// then an error will be returned.
-	GetByName(name string) (*domain.Role, error)
+	// GetByName searches for a role by its name, ignoring case. If
+	// not found, then an error will be returned.
+	GetByName(ctx context.Context, name string) (*domain.Role, error)
+
+	// GetByID gets a role by its id. If not found, then an error
+	// will be returned.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Role, error)
+
+	// Create saves a new role along with its permissions.
+	Create(ctx context.Context, role *domain.Role) error
+
+	// Update saves the name and description of a role and replaces
+	// its permissions.
+	Update(ctx context.Context, role *domain.Role) error
+
+	// CountUsers returns how many users have the role.
+	CountUsers(ctx context.Context, id uuid.UUID) (int, error)
+
+	// Delete removes a role. When reassignTo is set, every user who had
+	// the role is given that role instead before it is removed.
+	Delete(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error
 }
 
 func NewRoleRepository(db *sqlx.DB) RoleRepository {
@@ -27,23 +47,34 @@ type roleRepository struct {
 	DB *sqlx.DB
 }
 
-func (r *roleRepository) GetAll() ([]domain.Role, error) {
+func (r *roleRepository) GetAll(ctx context.Context) ([]domain.Role, error) {
 	var roles []domain.Role
 
-	query := "SELECT id, name FROM roles"
+	query := "SELECT id, name, description, is_built_in FROM roles"
 
-	err := r.DB.Select(&roles, query)
+	err := r.DB.SelectContext(ctx, &roles, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all roles from database: %w", err)
 	}
 
+	permissionQuery := `
+		SELECT rp.role_id, p.name
+		FROM permissions p
+		INNER JOIN role_permissions rp
+			ON rp.permission_id = p.id
+	`
+
+	if err := attachPermissions(ctx, r.DB, roles, permissionQuery); err != nil {
+		return nil, err
+	}
+
 	return roles, nil
 }
 
-func (r *roleRepository) GetByName(name string) (*domain.Role, error) {
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*domain.Role, error) {
 	var role domain.Role
-	query := "SELECT id, name FROM roles WHERE name = ?"
-	err := r.DB.Get(&role, query, name)
+	query := "SELECT id, name, description, is_built_in FROM roles WHERE name = ? COLLATE NOCASE"
+	err := r.DB.GetContext(ctx, &role, query, name)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, shared.ErrNotFound
@@ -53,5 +84,199 @@ func (r *roleRepository) GetByName(name string) (*domain.Role, error) {
 		return nil, fmt.Errorf("failed to get role by name: %w", err)
 	}
 
-	return &role, nil
+	roles := []domain.Role{role}
+	if err := attachPermissions(ctx, r.DB, roles, rolePermissionsQuery, role.ID); err != nil {
+		return nil, err
+	}
+
+	return &roles[0], nil
+}
+
+func (r *roleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
+	var role domain.Role
+	query := "SELECT id, name, description, is_built_in FROM roles WHERE id = ?"
+	err := r.DB.GetContext(ctx, &role, query, id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, shared.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role by id: %w", err)
+	}
+
+	roles := []domain.Role{role}
+	if err := attachPermissions(ctx, r.DB, roles, rolePermissionsQuery, role.ID); err != nil {
+		return nil, err
+	}
+
+	return &roles[0], nil
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *domain.Role) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO roles (id, name, description, is_built_in)
+		VALUES (:id, :name, :description, :is_built_in)
+	`
+
+	if _, err := tx.NamedExecContext(ctx, query, role); err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	if err := insertPermissions(ctx, tx, role); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *roleRepository) Update(ctx context.Context, role *domain.Role) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "UPDATE roles SET name = :name, description = :description WHERE id = :id"
+
+	result, err := tx.NamedExecContext(ctx, query, role)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return shared.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = ?", role.ID); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+
+	if err := insertPermissions(ctx, tx, role); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *roleRepository) CountUsers(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+
+	err := r.DB.GetContext(ctx, &count, "SELECT COUNT(*) FROM user_roles WHERE role_id = ?", id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *roleRepository) Delete(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if reassignTo != nil {
+		query := `
+			INSERT OR IGNORE INTO user_roles (user_id, role_id)
+			SELECT user_id, ? FROM user_roles WHERE role_id = ?
+		`
+
+		if _, err := tx.ExecContext(ctx, query, *reassignTo, id); err != nil {
+			return fmt.Errorf("failed to reassign users: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_roles WHERE role_id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove role from users: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove role permissions: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return shared.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// insertPermissions grants each of the role's permissions by name.
+func insertPermissions(ctx context.Context, tx *sqlx.Tx, role *domain.Role) error {
+	query := `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT ?, id FROM permissions WHERE name = ?
+	`
+
+	for _, permission := range role.Permissions {
+		if _, err := tx.ExecContext(ctx, query, role.ID, permission); err != nil {
+			return fmt.Errorf("failed to grant permission: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rolePermissionsQuery selects the permissions granted to a single role.
+const rolePermissionsQuery = `
+	SELECT rp.role_id, p.name
+	FROM permissions p
+	INNER JOIN role_permissions rp
+		ON rp.permission_id = p.id
+	WHERE rp.role_id = ?
+`
+
+// rolePermission is a row from a query that selects a role id and the
+// name of a permission granted to it.
+type rolePermission struct {
+	RoleID uuid.UUID `db:"role_id"`
+	Name   string    `db:"name"`
+}
+
+// attachPermissions runs a query that selects rolePermission rows and adds
+// each permission to the matching role. Every role ends up with a non-nil
+// permission list.
+func attachPermissions(ctx context.Context, db sqlx.QueryerContext, roles []domain.Role, query string, args ...any) error {
+	var rows []rolePermission
+
+	if err := sqlx.SelectContext(ctx, db, &rows, query, args...); err != nil {
+		return fmt.Errorf("failed to get role permissions: %w", err)
+	}
+
+	for i := range roles {
+		roles[i].Permissions = make([]string, 0)
+		for _, row := range rows {
+			if row.RoleID == roles[i].ID {
+				roles[i].Permissions = append(roles[i].Permissions, row.Name)
+			}
+		}
+	}
+
+	return nil
 }
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -12,16 +14,30 @@ import (
 type SessionRepository interface {
 	// GetByID gets a session by its id. If a session is not found
 	// then the returned session will be nil and no error will be returned.
-	GetByID(id uuid.UUID) (*domain.Session, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error)
+
+	// GetActiveByUserID returns the user's unexpired sessions, most
+	// recently used first.
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Session, error)
 
 	// Create saves a new session.
-	Create(session *domain.Session) error
+	Create(ctx context.Context, session *domain.Session) error
 
-	// Update saves the session details
-	Update(session *domain.Session) error
+	// Update saves the session expiration and last seen time.
+	Update(ctx context.Context, session *domain.Session) error
 
 	// DeleteByID deletes the session with the given id.
-	DeleteByID(id uuid.UUID) error
+	DeleteByID(ctx context.Context, id uuid.UUID) error
+
+	// DeleteByUserID deletes every session that belongs to the user.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+
+	// DeleteOthersByUserID deletes every session that belongs to the user
+	// except the one with the given id.
+	DeleteOthersByUserID(ctx context.Context, userID uuid.UUID, keepID uuid.UUID) error
+
+	// CountActive returns how many sessions have not expired.
+	CountActive(ctx context.Context) (int, error)
 }
 
 type sqliteSessionRepository struct {
@@ -32,15 +48,15 @@ func NewSessionRepository(db *sqlx.DB) SessionRepository {
 	return &sqliteSessionRepository{db: db}
 }
 
-func (r *sqliteSessionRepository) GetByID(id uuid.UUID) (*domain.Session, error) {
+func (r *sqliteSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
 	var session domain.Session
 
 	query := `
-		SELECT id, token, user_id, expires_at
+		SELECT id, token, key_id, user_id, expires_at, created_at, last_seen_at, ip_address, user_agent
 		FROM sessions
 		WHERE id = ?`
 
-	err := r.db.Get(&session, query, id)
+	err := r.db.GetContext(ctx, &session, query, id)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -52,13 +68,42 @@ func (r *sqliteSessionRepository) GetByID(id uuid.UUID) (*domain.Session, error)
 	return &session, nil
 }
 
-func (r *sqliteSessionRepository) Create(session *domain.Session) error {
+func (r *sqliteSessionRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	sessions := make([]domain.Session, 0)
+
+	query := `
+		SELECT id, token, key_id, user_id, expires_at, created_at, last_seen_at, ip_address, user_agent
+		FROM sessions
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY last_seen_at DESC`
+
+	err := r.db.SelectContext(ctx, &sessions, query, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("session repository get active by user id error: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sqliteSessionRepository) Create(ctx context.Context, session *domain.Session) error {
 	query := `
-		INSERT INTO sessions (id, token, user_id, expires_at)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO sessions (id, token, key_id, user_id, expires_at, created_at, last_seen_at, ip_address, user_agent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	rows, err := r.db.Exec(query, session.ID, session.Token, session.UserID, session.ExpiresAt)
+	rows, err := r.db.ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.Token,
+		session.KeyID,
+		session.UserID,
+		session.ExpiresAt,
+		session.CreatedAt,
+		session.LastSeenAt,
+		session.IPAddress,
+		session.UserAgent)
+
 	if err != nil {
 		return fmt.Errorf("create session repository error: %w", err)
 	}
@@ -75,13 +120,13 @@ func (r *sqliteSessionRepository) Create(session *domain.Session) error {
 	return nil
 }
 
-func (r *sqliteSessionRepository) Update(session *domain.Session) error {
+func (r *sqliteSessionRepository) Update(ctx context.Context, session *domain.Session) error {
 	query := `
-		UPDATE sessions SET expires_at = ?
+		UPDATE sessions SET expires_at = ?, last_seen_at = ?
 		WHERE id = ?
 	`
 
-	rows, err := r.db.Exec(query, session.ExpiresAt, session.ID)
+	rows, err := r.db.ExecContext(ctx, query, session.ExpiresAt, session.LastSeenAt, session.ID)
 	if err != nil {
 		return fmt.Errorf("session repository update error: %w", err)
 	}
@@ -98,16 +143,55 @@ func (r *sqliteSessionRepository) Update(session *domain.Session) error {
 	return nil
 }
 
-func (r *sqliteSessionRepository) DeleteByID(id uuid.UUID) error {
+func (r *sqliteSessionRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
 	query := `
 		DELETE FROM sessions
 		WHERE id = ?
 	`
 
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("delete session by id error: %w", err)
 	}
 
 	return nil
 }
+
+func (r *sqliteSessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		DELETE FROM sessions
+		WHERE user_id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("delete sessions by user id error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteSessionRepository) DeleteOthersByUserID(ctx context.Context, userID uuid.UUID, keepID uuid.UUID) error {
+	query := `
+		DELETE FROM sessions
+		WHERE user_id = ? AND id <> ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, keepID)
+	if err != nil {
+		return fmt.Errorf("delete other sessions by user id error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteSessionRepository) CountActive(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM sessions WHERE expires_at > ?", time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	return count, nil
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -13,28 +16,80 @@ import (
 
 type UserRepository interface {
 	// Fetch a user by ID, when not found, returns an error.
-	GetByID(id uuid.UUID) (*domain.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+
+	// Fetch a soft deleted user by ID, when not found returns an error.
+	// Every other method ignores soft deleted users.
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 
 	// Fetch a user by Username, when not found returns an error.
-	GetByUsername(username string) (*domain.User, error)
+	GetByUsername(ctx context.Context, username string) (*domain.User, error)
+
+	// UsernameExists returns true when any user has the username,
+	// including soft deleted users who still hold it.
+	UsernameExists(ctx context.Context, username string) (bool, error)
+
+	// Fetch all users with the given email address. Email addresses
+	// are not unique, so more than one user may be returned.
+	GetAllByEmail(ctx context.Context, email string) ([]domain.User, error)
 
-	// Get all users.
-	//
-	// There are never expected to be more than 25 users since
-	// this is a local application. If that changes, we can
-	// rewrite this to produce paged results.
-	GetAll() ([]domain.User, error)
+	// Search returns one page of users matching the query along with
+	// the number of users that match across every page.
+	Search(ctx context.Context, query domain.UserQuery) ([]domain.User, int, error)
 
 	// Create a new user.
-	Create(user *domain.User) error
+	Create(ctx context.Context, user *domain.User) error
 
 	// Update an existing user's basic details, does not update
-	// collection objects like roles.
-	UpdateBasic(user *domain.User) error
+	// collection objects like roles. Whether the user is disabled is only
+	// changed by Disable and Enable so a stale copy of the user can't undo
+	// either of them.
+	UpdateBasic(ctx context.Context, user *domain.User) error
+
+	// Update an existing user's multi-factor authentication settings.
+	UpdateMFA(ctx context.Context, user *domain.User) error
+
+	// Update an existing user's password hash and whether they
+	// must change it at their next login.
+	UpdatePassword(ctx context.Context, user *domain.User) error
+
+	// Disable saves the user as disabled. Disabling the last enabled
+	// Administrator fails.
+	Disable(ctx context.Context, user *domain.User) error
+
+	// Enable saves the user as enabled.
+	Enable(ctx context.Context, user *domain.User) error
+
+	// SoftDelete hides the user until they are restored or purged. Deleting
+	// the last enabled Administrator fails.
+	SoftDelete(ctx context.Context, user *domain.User) error
+
+	// Restore brings back a soft deleted user.
+	Restore(ctx context.Context, user *domain.User) error
 
 	// Delete an existing user and all of the associated data.
 	// This is unrecoverable.
-	Delete(user *domain.User) error
+	Delete(ctx context.Context, user *domain.User) error
+
+	// PurgeDeletedBefore permanently deletes every user that was soft
+	// deleted before the cutoff and returns the ids of the removed users.
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error)
+
+	// SetRoles replaces all of the user's roles in a single transaction.
+	// Taking the Administrator role from the last enabled Administrator fails.
+	SetRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error
+
+	// AddRole grants a role to the user. Granting a role the user
+	// already has does nothing.
+	AddRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID) error
+
+	// RemoveRole revokes a role from the user. Revoking a role the
+	// user doesn't have does nothing. Taking the Administrator role from
+	// the last enabled Administrator fails.
+	RemoveRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID) error
+
+	// Count returns how many users exist, including soft deleted users.
+	Count(ctx context.Context) (int, error)
 }
 
 type sqliteUserRepository struct {
@@ -46,18 +101,30 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 	return &sqliteUserRepository{db: db}
 }
 
-func (r *sqliteUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
+func (r *sqliteUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.getUser(ctx, "id = ? AND deleted_at IS NULL", id.String())
+}
+
+func (r *sqliteUserRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.getUser(ctx, "id = ? AND deleted_at IS NOT NULL", id.String())
+}
+
+func (r *sqliteUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.getUser(ctx, "LOWER(username) = ? AND deleted_at IS NULL", strings.ToLower(username))
+}
+
+// getUser gets the single user matching the condition along with their roles.
+func (r *sqliteUserRepository) getUser(ctx context.Context, condition string, args ...any) (*domain.User, error) {
 	var user domain.User
 
 	query := `
 		SELECT id, username, email, first_name, last_name, password_hash,
-			last_login, failed_login_attempts, last_failed_login_attempt, 
-			is_disabled, created_at, updated_at
+			last_login, failed_login_attempts, last_failed_login_attempt,
+			is_disabled, mfa_secret, mfa_enabled, must_change_password, created_at, updated_at, deleted_at
 		FROM users
-		WHERE id = ?
-	`
+		WHERE ` + condition
 
-	err := r.db.Get(&user, query, id.String())
+	err := r.db.GetContext(ctx, &user, query, args...)
 	if err == sql.ErrNoRows {
 		return nil, shared.ErrNotFound // not found
 	}
@@ -66,7 +133,7 @@ func (r *sqliteUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
 		return nil, err
 	}
 
-	roles, err := r.getRolesForUser(user.ID)
+	roles, err := r.getRolesForUser(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -76,66 +143,137 @@ func (r *sqliteUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *sqliteUserRepository) GetByUsername(username string) (*domain.User, error) {
-	var user domain.User
+func (r *sqliteUserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var count int
+
+	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM users WHERE LOWER(username) = ?", strings.ToLower(username))
+	if err != nil {
+		return false, fmt.Errorf("failed to check username: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *sqliteUserRepository) GetAllByEmail(ctx context.Context, email string) ([]domain.User, error) {
+	users := make([]domain.User, 0)
 
 	query := `
 		SELECT id, username, email, first_name, last_name, password_hash,
 			last_login, failed_login_attempts, last_failed_login_attempt,
-			is_disabled, created_at, updated_at
+			is_disabled, mfa_secret, mfa_enabled, must_change_password, created_at, updated_at, deleted_at
 		FROM users
-		WHERE LOWER(username) = ?
+		WHERE LOWER(email) = ? AND deleted_at IS NULL
 	`
 
-	err := r.db.Get(&user, query, strings.ToLower(username))
-	if err == sql.ErrNoRows {
-		return nil, shared.ErrNotFound
-	}
-
+	err := r.db.SelectContext(ctx, &users, query, strings.ToLower(email))
 	if err != nil {
 		return nil, err
 	}
 
-	roles, err := r.getRolesForUser(user.ID)
-	if err != nil {
-		return nil, err
+	return users, nil
+}
+
+// userSortColumns maps the sort keys of a UserQuery to their columns.
+var userSortColumns = map[string]string{
+	"username":  "username COLLATE NOCASE",
+	"email":     "email COLLATE NOCASE",
+	"firstName": "first_name COLLATE NOCASE",
+	"lastName":  "last_name COLLATE NOCASE",
+	"createdAt": "created_at",
+	"lastLogin": "last_login",
+	"deletedAt": "deleted_at",
+}
+
+func (r *sqliteUserRepository) Search(ctx context.Context, query domain.UserQuery) ([]domain.User, int, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	if query.Deleted {
+		conditions = []string{"deleted_at IS NOT NULL"}
 	}
 
-	user.Roles = roles
+	args := make([]any, 0)
 
-	return &user, nil
-}
+	if query.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(query.Search)) + "%"
+		conditions = append(conditions, `(
+			LOWER(username) LIKE ? ESCAPE '\'
+			OR LOWER(email) LIKE ? ESCAPE '\'
+			OR LOWER(first_name || ' ' || last_name) LIKE ? ESCAPE '\'
+		)`)
+		args = append(args, pattern, pattern, pattern)
+	}
 
-func (r *sqliteUserRepository) GetAll() ([]domain.User, error) {
-	var users []domain.User
+	if query.RoleID != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = users.id AND ur.role_id = ?)")
+		args = append(args, query.RoleID)
+	}
 
-	query := `
-		SELECT id, username, email, first_name, last_name, 
-			last_login, failed_login_attempts, last_failed_login_attempt, 
-			is_disabled, created_at, updated_at
+	if query.Disabled != nil {
+		conditions = append(conditions, "is_disabled = ?")
+		args = append(args, *query.Disabled)
+	}
+
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM users "+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	column, ok := userSortColumns[query.Sort]
+	if !ok {
+		column = userSortColumns["username"]
+	}
+
+	order := "ASC"
+	if query.Order == "desc" {
+		order = "DESC"
+	}
+
+	selectQuery := fmt.Sprintf(`
+		SELECT id, username, email, first_name, last_name,
+			last_login, failed_login_attempts, last_failed_login_attempt,
+			is_disabled, mfa_enabled, must_change_password, created_at, updated_at, deleted_at
 		FROM users
-	`
+		%s
+		ORDER BY %s %s, id
+		LIMIT ? OFFSET ?
+	`, where, column, order)
 
-	err := r.db.Select(&users, query)
+	users := make([]domain.User, 0)
+	err = r.db.SelectContext(ctx, &users, selectQuery, append(args, query.PageSize, query.Offset())...)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
 	}
 
+	userIDs := make([]uuid.UUID, len(users))
 	for idx, user := range users {
-		roles, err := r.getRolesForUser(user.ID)
-		if err != nil {
-			return nil, err
-		}
+		userIDs[idx] = user.ID
+	}
 
-		user.Roles = roles
-		users[idx] = user
+	roles, err := r.getRolesForUsers(ctx, userIDs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return users, nil
+	for idx := range users {
+		users[idx].Roles = roles[users[idx].ID]
+		if users[idx].Roles == nil {
+			users[idx].Roles = make([]domain.Role, 0)
+		}
+	}
+
+	return users, total, nil
 }
 
-func (r *sqliteUserRepository) Create(user *domain.User) error {
-	tx, err := r.db.Beginx()
+// escapeLike escapes the wildcard characters in a LIKE pattern so they
+// are matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (r *sqliteUserRepository) Create(ctx context.Context, user *domain.User) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
@@ -147,7 +285,7 @@ func (r *sqliteUserRepository) Create(user *domain.User) error {
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := tx.Exec(query, user.ID, user.Username, user.Email, user.FirstName, user.LastName, user.PasswordHash)
+	result, err := tx.ExecContext(ctx, query, user.ID, user.Username, user.Email, user.FirstName, user.LastName, user.PasswordHash)
 	if err != nil {
 		return err
 	}
@@ -163,7 +301,7 @@ func (r *sqliteUserRepository) Create(user *domain.User) error {
 
 	for _, role := range user.Roles {
 		query := "INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)"
-		result, err := tx.Exec(query, user.ID, role.ID)
+		result, err := tx.ExecContext(ctx, query, user.ID, role.ID)
 		if err != nil {
 			return err
 		}
@@ -181,29 +319,30 @@ func (r *sqliteUserRepository) Create(user *domain.User) error {
 	return tx.Commit()
 }
 
-func (r *sqliteUserRepository) UpdateBasic(user *domain.User) error {
+func (r *sqliteUserRepository) UpdateBasic(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users SET 
+			username = ?,
 			email = ?, 
 			first_name = ?,
 			last_name = ?,
 			last_login = ?,
 			failed_login_attempts = ?,
 			last_failed_login_attempt = ?,
-			is_disabled = ?,
 			updated_at = ?
 		WHERE id = ?
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(
+		ctx,
 		query,
+		user.Username,
 		user.Email,
 		user.FirstName,
 		user.LastName,
 		user.LastLogin,
 		user.FailedLoginAttempts,
 		user.LastFailedLoginAttempt,
-		user.IsDisabled,
 		user.UpdatedAt,
 		user.ID)
 
@@ -223,10 +362,178 @@ func (r *sqliteUserRepository) UpdateBasic(user *domain.User) error {
 	return nil
 }
 
-func (r *sqliteUserRepository) Delete(user *domain.User) error {
+func (r *sqliteUserRepository) UpdateMFA(ctx context.Context, user *domain.User) error {
+	query := `
+		UPDATE users SET
+			mfa_secret = ?,
+			mfa_enabled = ?,
+			mfa_last_step = CASE WHEN ? THEN mfa_last_step ELSE 0 END,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	// The last used TOTP step belongs to the old secret, so it is cleared
+	// whenever MFA is turned off or enrollment starts again.
+	result, err := r.db.ExecContext(ctx, query, user.MFASecret, user.MFAEnabled, user.MFAEnabled, user.UpdatedAt, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update user mfa settings: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows != 1 {
+		return fmt.Errorf("expected to update 1 user row, but rows affected was %d", rows)
+	}
+
+	return nil
+}
+
+func (r *sqliteUserRepository) UpdatePassword(ctx context.Context, user *domain.User) error {
+	query := `
+		UPDATE users SET
+			password_hash = ?,
+			must_change_password = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, user.PasswordHash, user.MustChangePassword, user.UpdatedAt, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows != 1 {
+		return fmt.Errorf("expected to update 1 user row, but rows affected was %d", rows)
+	}
+
+	return nil
+}
+
+func (r *sqliteUserRepository) Disable(ctx context.Context, user *domain.User) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "UPDATE users SET is_disabled = 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL"
+
+	result, err := tx.ExecContext(ctx, query, user.UpdatedAt, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to disable user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return shared.ErrNotFound
+	}
+
+	if user.HasRole(domain.Administrator) {
+		if err := checkAdministratorRemains(ctx, tx, "disabled"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteUserRepository) Enable(ctx context.Context, user *domain.User) error {
+	query := "UPDATE users SET is_disabled = 0, updated_at = ? WHERE id = ? AND deleted_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, user.UpdatedAt, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to enable user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return shared.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *sqliteUserRepository) SoftDelete(ctx context.Context, user *domain.User) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if err := setDeletedAt(ctx, tx, user, "deleted_at IS NULL"); err != nil {
+		return err
+	}
+
+	if user.HasRole(domain.Administrator) && !user.IsDisabled {
+		if err := checkAdministratorRemains(ctx, tx, "removed"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteUserRepository) Restore(ctx context.Context, user *domain.User) error {
+	return setDeletedAt(ctx, r.db, user, "deleted_at IS NOT NULL")
+}
+
+// setDeletedAt saves the user's deleted_at and updated_at when the
+// condition holds for the stored user.
+func setDeletedAt(ctx context.Context, db sqlx.ExecerContext, user *domain.User, condition string) error {
+	query := "UPDATE users SET deleted_at = ?, updated_at = ? WHERE id = ? AND " + condition
+
+	result, err := db.ExecContext(ctx, query, user.DeletedAt, user.UpdatedAt, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update user deleted at: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return shared.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *sqliteUserRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	purged := make([]uuid.UUID, 0)
+
+	query := "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING id"
+
+	err := r.db.SelectContext(ctx, &purged, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	return purged, nil
+}
+
+func (r *sqliteUserRepository) Delete(ctx context.Context, user *domain.User) error {
 	query := "DELETE FROM users WHERE id = ?"
 
-	result, err := r.db.Exec(query, user.ID)
+	result, err := r.db.ExecContext(ctx, query, user.ID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -243,25 +550,246 @@ func (r *sqliteUserRepository) Delete(user *domain.User) error {
 	return nil
 }
 
-func (r *sqliteUserRepository) getRolesForUser(userID uuid.UUID) ([]domain.Role, error) {
-	var roles []domain.Role
+func (r *sqliteUserRepository) getRolesForUser(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
+	roles, err := r.getRolesForUsers(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if roles[userID] == nil {
+		return make([]domain.Role, 0), nil
+	}
+
+	return roles[userID], nil
+}
+
+// userRoleRow is a role held by a user with the names of its permissions
+// joined by commas.
+type userRoleRow struct {
+	UserID      uuid.UUID `db:"user_id"`
+	ID          uuid.UUID `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	IsBuiltIn   bool      `db:"is_built_in"`
+	Permissions string    `db:"permissions"`
+}
+
+// getRolesForUsers loads the roles and permissions of every user in a
+// single query. The result is keyed by user ID.
+func (r *sqliteUserRepository) getRolesForUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]domain.Role, error) {
+	result := make(map[uuid.UUID][]domain.Role, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT ur.user_id, r.id, r.name, r.description, r.is_built_in,
+			COALESCE(GROUP_CONCAT(p.name), '') AS permissions
+		FROM user_roles ur
+		INNER JOIN roles r
+			ON r.id = ur.role_id
+		LEFT JOIN role_permissions rp
+			ON rp.role_id = r.id
+		LEFT JOIN permissions p
+			ON p.id = rp.permission_id
+		WHERE ur.user_id IN (?)
+		GROUP BY ur.user_id, r.id
+		ORDER BY r.name
+	`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []userRoleRow
+	err = r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles for users: %w", err)
+	}
+
+	for _, row := range rows {
+		permissions := make([]string, 0)
+		if row.Permissions != "" {
+			permissions = strings.Split(row.Permissions, ",")
+			slices.Sort(permissions)
+		}
+
+		result[row.UserID] = append(result[row.UserID], domain.Role{
+			ID:          row.ID,
+			Name:        row.Name,
+			Description: row.Description,
+			IsBuiltIn:   row.IsBuiltIn,
+			Permissions: permissions,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *sqliteUserRepository) SetRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var removed []uuid.UUID
+	err = tx.SelectContext(ctx, &removed, "DELETE FROM user_roles WHERE user_id = ? RETURNING role_id", userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user roles: %w", err)
+	}
+
+	for _, roleID := range roleIDs {
+		query := "INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)"
+		result, err := tx.ExecContext(ctx, query, userID, roleID)
+		if err != nil {
+			return fmt.Errorf("failed to create user role: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return fmt.Errorf("expected to create 1 new user role, but affected was %d", affected)
+		}
+	}
+
+	for _, roleID := range removed {
+		if slices.Contains(roleIDs, roleID) {
+			continue
+		}
+
+		if err := checkRoleRemoval(ctx, tx, userID, roleID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteUserRepository) AddRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "INSERT OR IGNORE INTO user_roles (user_id, role_id) VALUES (?, ?)"
+	_, err = tx.ExecContext(ctx, query, userID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to create user role: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteUserRepository) RemoveRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "DELETE FROM user_roles WHERE user_id = ? AND role_id = ?"
+	result, err := tx.ExecContext(ctx, query, userID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user role: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected > 0 {
+		if err := checkRoleRemoval(ctx, tx, userID, roleID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteUserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM users")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// countEnabledWithRole returns how many users that are not disabled or
+// deleted have the role.
+func countEnabledWithRole(ctx context.Context, db sqlx.QueryerContext, roleName string) (int, error) {
+	var count int
 
 	query := `
-		SELECT r.id, r.name
-		FROM roles r
-		INNER JOIN user_roles ur 
-			ON ur.role_id = r.id
-		WHERE ur.user_id = ?
+		SELECT COUNT(DISTINCT u.id)
+		FROM users u
+		INNER JOIN user_roles ur
+			ON ur.user_id = u.id
+		INNER JOIN roles r
+			ON r.id = ur.role_id
+		WHERE r.name = ? AND u.is_disabled = 0 AND u.deleted_at IS NULL
 	`
 
-	err := r.db.Select(&roles, query, userID)
-	if err == sql.ErrNoRows {
-		return make([]domain.Role, 0), nil
+	err := sqlx.GetContext(ctx, db, &count, query, roleName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
 	}
 
+	return count, nil
+}
+
+// checkRoleRemoval checks, after roleID has been taken from the user in tx,
+// that an enabled Administrator is left when the role was Administrator and
+// the user was enabled.
+func checkRoleRemoval(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, roleID uuid.UUID) error {
+	var removedAdministrator bool
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM roles r
+			INNER JOIN users u
+				ON u.id = ?
+			WHERE r.id = ? AND r.name = ? AND u.is_disabled = 0 AND u.deleted_at IS NULL
+		)
+	`
+
+	err := tx.GetContext(ctx, &removedAdministrator, query, userID, roleID, domain.Administrator)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to check removed role: %w", err)
+	}
+
+	if !removedAdministrator {
+		return nil
 	}
 
-	return roles, nil
+	return checkAdministratorRemains(ctx, tx, "removed")
+}
+
+// checkAdministratorRemains returns an error naming the action when no
+// enabled Administrator is left. It runs in the transaction that removed
+// or disabled one, after the change, so the transaction already holds the
+// write lock and concurrent changes can't both count the same last
+// Administrator.
+func checkAdministratorRemains(ctx context.Context, tx *sqlx.Tx, action string) error {
+	count, err := countEnabledWithRole(ctx, tx, domain.Administrator)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("%w: the last Administrator can't be %s", shared.ErrBadRequest, action)
+	}
+
+	return nil
 }
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+type WebAuthnRepository interface {
+	// GetCredentialsByUserID returns all authenticators registered to the user.
+	GetCredentialsByUserID(ctx context.Context, userID uuid.UUID) ([]domain.WebAuthnCredential, error)
+
+	// GetCredentialByID gets an authenticator by its id, when not found returns an error.
+	GetCredentialByID(ctx context.Context, id uuid.UUID) (*domain.WebAuthnCredential, error)
+
+	// CreateCredential saves a newly registered authenticator.
+	CreateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error
+
+	// UpdateCredential saves the name, credential record and last used time.
+	UpdateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error
+
+	// DeleteCredential removes a registered authenticator.
+	DeleteCredential(ctx context.Context, credential *domain.WebAuthnCredential) error
+
+	// GetCeremonyByID gets a pending ceremony by its id. If a ceremony is not
+	// found then the returned ceremony will be nil and no error will be returned.
+	GetCeremonyByID(ctx context.Context, id uuid.UUID) (*domain.WebAuthnCeremony, error)
+
+	// CreateCeremony saves the state of a new ceremony.
+	CreateCeremony(ctx context.Context, ceremony *domain.WebAuthnCeremony) error
+
+	// DeleteCeremonyByID deletes the ceremony with the given id.
+	DeleteCeremonyByID(ctx context.Context, id uuid.UUID) error
+}
+
+type sqliteWebAuthnRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebAuthnRepository creates a new WebAuthn repository.
+func NewWebAuthnRepository(db *sqlx.DB) WebAuthnRepository {
+	return &sqliteWebAuthnRepository{db: db}
+}
+
+func (r *sqliteWebAuthnRepository) GetCredentialsByUserID(ctx context.Context, userID uuid.UUID) ([]domain.WebAuthnCredential, error) {
+	credentials := make([]domain.WebAuthnCredential, 0)
+
+	query := `
+		SELECT id, user_id, credential_id, name, credential, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE user_id = ?
+		ORDER BY created_at
+	`
+
+	err := r.db.SelectContext(ctx, &credentials, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+func (r *sqliteWebAuthnRepository) GetCredentialByID(ctx context.Context, id uuid.UUID) (*domain.WebAuthnCredential, error) {
+	var credential domain.WebAuthnCredential
+
+	query := `
+		SELECT id, user_id, credential_id, name, credential, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE id = ?
+	`
+
+	err := r.db.GetContext(ctx, &credential, query, id)
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credential by id: %w", err)
+	}
+
+	return &credential, nil
+}
+
+func (r *sqliteWebAuthnRepository) CreateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (id, user_id, credential_id, name, credential, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		credential.ID,
+		credential.UserID,
+		credential.CredentialID,
+		credential.Name,
+		credential.Credential,
+		credential.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected err: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to create 1 webauthn credential, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteWebAuthnRepository) UpdateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	query := `
+		UPDATE webauthn_credentials SET
+			name = ?,
+			credential = ?,
+			last_used_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, credential.Name, credential.Credential, credential.LastUsedAt, credential.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo update rows affected error: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to update 1 webauthn credential, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteWebAuthnRepository) DeleteCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	query := "DELETE FROM webauthn_credentials WHERE id = ?"
+
+	result, err := r.db.ExecContext(ctx, query, credential.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to delete 1 record, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteWebAuthnRepository) GetCeremonyByID(ctx context.Context, id uuid.UUID) (*domain.WebAuthnCeremony, error) {
+	var ceremony domain.WebAuthnCeremony
+
+	query := `
+		SELECT id, user_id, session_data, expires_at
+		FROM webauthn_ceremonies
+		WHERE id = ?`
+
+	err := r.db.GetContext(ctx, &ceremony, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("webauthn repository get ceremony by id error: %w", err)
+	}
+
+	return &ceremony, nil
+}
+
+func (r *sqliteWebAuthnRepository) CreateCeremony(ctx context.Context, ceremony *domain.WebAuthnCeremony) error {
+	query := `
+		INSERT INTO webauthn_ceremonies (id, user_id, session_data, expires_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, ceremony.ID, ceremony.UserID, ceremony.SessionData, ceremony.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create webauthn ceremony repository error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected err: %w", err)
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to create 1 webauthn ceremony, rows affected: %d", affected)
+	}
+
+	return nil
+}
+
+func (r *sqliteWebAuthnRepository) DeleteCeremonyByID(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM webauthn_ceremonies WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete webauthn ceremony by id error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+type AccessTokenService interface {
+	// ListTokens returns the actor's personal access tokens.
+	ListTokens(ctx context.Context, actor *domain.User) ([]domain.AccessTokenRead, error)
+
+	// CreateToken creates a new personal access token for the actor.
+	// The raw token is only ever returned here.
+	CreateToken(ctx context.Context, actor *domain.User, request domain.AccessTokenCreateRequest) (*domain.AccessTokenCreated, error)
+
+	// RevokeToken deletes one of the actor's tokens.
+	RevokeToken(ctx context.Context, actor *domain.User, tokenID uuid.UUID) error
+}
+
+func NewAccessTokenService(accessTokenRepository repository.AccessTokenRepository, keyring *crypto.Keyring) AccessTokenService {
+	return &accessTokenService{
+		accessTokenRepository: accessTokenRepository,
+		keyring:               keyring,
+	}
+}
+
+type accessTokenService struct {
+	accessTokenRepository repository.AccessTokenRepository
+	keyring               *crypto.Keyring
+}
+
+func (s *accessTokenService) ListTokens(ctx context.Context, actor *domain.User) ([]domain.AccessTokenRead, error) {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.ListTokens")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	tokens, err := s.accessTokenRepository.GetByUserID(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenList := make([]domain.AccessTokenRead, len(tokens))
+	for idx, token := range tokens {
+		tokenList[idx] = domain.NewAccessTokenRead(&token)
+	}
+
+	return tokenList, nil
+}
+
+func (s *accessTokenService) CreateToken(ctx context.Context, actor *domain.User, request domain.AccessTokenCreateRequest) (*domain.AccessTokenCreated, error) {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.CreateToken")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	verifier, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate access token verifier: %w", err)
+	}
+
+	keyID, hashed := s.keyring.Sign(verifier)
+	lifetime := time.Duration(request.ExpiresInDays) * 24 * time.Hour
+
+	token, err := domain.NewAccessToken(actor.ID, request.Name, hashed, keyID, request.Scopes, lifetime)
+	if err != nil {
+		return nil, fmt.Errorf("access token creation failed: %w", err)
+	}
+
+	err = s.accessTokenRepository.Create(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save access token: %w", err)
+	}
+
+	created := &domain.AccessTokenCreated{
+		AccessTokenRead: domain.NewAccessTokenRead(token),
+		Token:           crypto.EncodeSessionToken(token.ID.String(), verifier),
+	}
+
+	return created, nil
+}
+
+func (s *accessTokenService) RevokeToken(ctx context.Context, actor *domain.User, tokenID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.RevokeToken")
+	defer span.End()
+
+	if actor == nil {
+		return shared.ErrUnauthorized
+	}
+
+	token, err := s.accessTokenRepository.GetByID(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	if token == nil || token.UserID != actor.ID {
+		return shared.ErrNotFound
+	}
+
+	err = s.accessTokenRepository.DeleteByID(ctx, token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+type AuditService interface {
+	// Record saves an audit event. A failure to save is logged rather
+	// than returned so auditing never stops the action being audited.
+	Record(ctx context.Context, event *domain.AuditEvent)
+
+	// GetEvents returns one page of audit events matching the query.
+	// The actor needs the audit:read permission.
+	GetEvents(ctx context.Context, actor *domain.User, query domain.AuditQuery) (*domain.Page[domain.AuditEventRead], error)
+
+	// ExportCSV writes the audit events matching the query to w as CSV,
+	// up to domain.MaxAuditExportRows. The actor needs the audit:read
+	// permission.
+	ExportCSV(ctx context.Context, actor *domain.User, query domain.AuditQuery, w io.Writer) error
+}
+
+type auditService struct {
+	auditRepository repository.AuditRepository
+	logger          *slog.Logger
+}
+
+func NewAuditService(auditRepository repository.AuditRepository, logger *slog.Logger) AuditService {
+	return &auditService{
+		auditRepository: auditRepository,
+		logger:          logger,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, event *domain.AuditEvent) {
+	ctx, span := tracer.Start(ctx, "AuditService.Record")
+	defer span.End()
+
+	if err := s.auditRepository.Create(ctx, event); err != nil {
+		s.logger.Error("failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+func (s *auditService) GetEvents(ctx context.Context, actor *domain.User, query domain.AuditQuery) (*domain.Page[domain.AuditEventRead], error) {
+	ctx, span := tracer.Start(ctx, "AuditService.GetEvents")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionAuditRead) {
+		return nil, shared.ErrForbidden
+	}
+
+	events, total, err := s.auditRepository.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	eventList := make([]domain.AuditEventRead, len(events))
+	for idx, event := range events {
+		eventList[idx] = domain.NewAuditEventRead(&event)
+	}
+
+	return domain.NewPage(eventList, total, query.Page, query.PageSize), nil
+}
+
+func (s *auditService) ExportCSV(ctx context.Context, actor *domain.User, query domain.AuditQuery, w io.Writer) error {
+	ctx, span := tracer.Start(ctx, "AuditService.ExportCSV")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionAuditRead) {
+		return shared.ErrForbidden
+	}
+
+	events, err := s.auditRepository.Export(ctx, query, domain.MaxAuditExportRows)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"id", "createdAt", "action", "actorId", "actorUsername",
+		"targetType", "targetId", "ipAddress", "userAgent", "details",
+	}
+
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write audit csv: %w", err)
+	}
+
+	for _, event := range events {
+		actorID := ""
+		if event.ActorID != nil {
+			actorID = event.ActorID.String()
+		}
+
+		record := []string{
+			event.ID.String(),
+			event.CreatedAt.UTC().Format(time.RFC3339),
+			csvCell(event.Action),
+			actorID,
+			csvCell(event.ActorUsername),
+			csvCell(event.TargetType),
+			csvCell(event.TargetID),
+			csvCell(event.IPAddress),
+			csvCell(event.UserAgent),
+			csvCell(event.Details),
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write audit csv: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell stops a spreadsheet from treating a value as a formula by
+// prefixing a quote when it starts with a character that begins one.
+// Usernames, user agents and details come from users, so an export could
+// otherwise run a formula such as =HYPERLINK(...) when opened.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -1,34 +1,77 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
 	"github.com/th3oth3rjak3/mainframe/internal/crypto"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/metrics"
 	"github.com/th3oth3rjak3/mainframe/internal/repository"
 	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
-const maximumLoginAttemptsAllowed = 5
-
 type AuthenticationService interface {
-	Login(request *domain.LoginRequest) (*LoginResult, error)
-	Logout(session *domain.Session) error
+	// Login verifies the user's password. When the user has multi-factor
+	// authentication enabled, no session is created and the result instead
+	// contains a challenge token that must be completed with CompleteMFALogin.
+	Login(ctx context.Context, request *domain.LoginRequest, client domain.ClientInfo) (*LoginResult, error)
+
+	// CompleteMFALogin finishes a login that is waiting on a second factor.
+	CompleteMFALogin(ctx context.Context, request *domain.MFALoginRequest, client domain.ClientInfo) (*LoginResult, error)
+
+	// LoginWithPasskey verifies a WebAuthn assertion and creates a session
+	// the same way a password login does.
+	LoginWithPasskey(ctx context.Context, request *domain.WebAuthnLoginFinishRequest, client domain.ClientInfo) (*LoginResult, error)
+
+	// Logout ends the actor's current session.
+	Logout(ctx context.Context, actor *domain.User, session *domain.Session, client domain.ClientInfo) error
+
+	// ChangePassword verifies the actor's current password and replaces it.
+	// Every session the actor has other than the current one is ended and
+	// their access tokens are revoked.
+	ChangePassword(
+		ctx context.Context,
+		actor *domain.User,
+		session *domain.Session,
+		request domain.PasswordChangeRequest,
+		client domain.ClientInfo,
+	) error
 }
 
 func NewAuthenticationService(
 	userRepo repository.UserRepository,
 	sessionRepo repository.SessionRepository,
+	accessTokenRepo repository.AccessTokenRepository,
+	mfaRepo repository.MFARepository,
+	mfaService MFAService,
+	webAuthnService WebAuthnService,
 	pwHasher domain.PasswordHasher,
-	hmacKey string,
+	keyring *crypto.Keyring,
+	lockoutPolicy domain.LockoutPolicy,
+	sessionDuration time.Duration,
+	auditService AuditService,
+	appMetrics *metrics.Metrics,
+	logger *slog.Logger,
 ) AuthenticationService {
 	return &authenticationService{
-		userRepository:    userRepo,
-		sessionRepository: sessionRepo,
-		passwordHasher:    pwHasher,
-		hmacKey:           hmacKey,
+		userRepository:        userRepo,
+		sessionRepository:     sessionRepo,
+		accessTokenRepository: accessTokenRepo,
+		mfaRepository:         mfaRepo,
+		mfaService:            mfaService,
+		webAuthnService:       webAuthnService,
+		passwordHasher:        pwHasher,
+		keyring:               keyring,
+		lockoutPolicy:         lockoutPolicy,
+		sessionDuration:       sessionDuration,
+		auditService:          auditService,
+		metrics:               appMetrics,
+		logger:                logger,
 	}
 }
 
@@ -36,76 +79,288 @@ type LoginResult struct {
 	User            *domain.User
 	Session         *domain.Session
 	RawSessionToken []byte
+
+	// MFARequired is true when the password was correct but a second factor
+	// is still needed. Session will be nil in this case.
+	MFARequired       bool
+	MFAChallengeToken string
 }
 
 type authenticationService struct {
-	userRepository    repository.UserRepository
-	sessionRepository repository.SessionRepository
-	passwordHasher    domain.PasswordHasher
-	hmacKey           string
+	userRepository        repository.UserRepository
+	sessionRepository     repository.SessionRepository
+	accessTokenRepository repository.AccessTokenRepository
+	mfaRepository         repository.MFARepository
+	mfaService            MFAService
+	webAuthnService       WebAuthnService
+	passwordHasher        domain.PasswordHasher
+	keyring               *crypto.Keyring
+	lockoutPolicy         domain.LockoutPolicy
+	sessionDuration       time.Duration
+	auditService          AuditService
+	metrics               *metrics.Metrics
+	logger                *slog.Logger
 }
 
-func (s *authenticationService) Login(request *domain.LoginRequest) (*LoginResult, error) {
+func (s *authenticationService) Login(ctx context.Context, request *domain.LoginRequest, client domain.ClientInfo) (*LoginResult, error) {
+	ctx, span := tracer.Start(ctx, "AuthenticationService.Login")
+	defer span.End()
+
 	var user *domain.User
-	user, err := s.userRepository.GetByUsername(request.Username)
-	if err != nil {
+	user, err := s.userRepository.GetByUsername(ctx, request.Username)
+	if err != nil && !errors.Is(err, shared.ErrNotFound) {
 		return nil, fmt.Errorf("failed to get by username: %w", err)
 	}
 
 	if user == nil {
 		_ = s.passwordHasher.FakeVerify(request.Password) // Prevent timing attack
+		s.recordLoginFailure(ctx, nil, client, "unknown username", map[string]any{"username": request.Username})
 		return nil, shared.ErrInvalidCredentials
 	}
 
+	// Locked accounts are rejected before the password is checked so
+	// guessing can't continue during the lockout window.
+	if s.lockoutPolicy.IsLocked(user, time.Now().UTC()) {
+		_ = s.passwordHasher.FakeVerify(request.Password)
+		return nil, s.rejectLockedLogin(ctx, user, client)
+	}
+
 	match, err := s.passwordHasher.Verify(request.Password, user.PasswordHash)
 	if err != nil {
 		return nil, fmt.Errorf("password verification failed: %w", err)
 	}
 
 	if !match {
-		err := s.handleFailedLogin(user)
+		err := s.handleFailedLogin(ctx, user, client, "invalid password")
 		return nil, err
 	}
 
-	if err = s.handleSuccessfulLogin(user); err != nil {
+	if user.IsDisabled {
+		s.recordLoginFailure(ctx, user, client, "disabled", nil)
+		return nil, shared.ErrAccountDisabled
+	}
+
+	if user.MFAEnabled {
+		challengeToken, err := s.createMFAChallengeForUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{User: user, MFARequired: true, MFAChallengeToken: challengeToken}, nil
+	}
+
+	return s.completeLogin(ctx, user, client, "password")
+}
+
+func (s *authenticationService) CompleteMFALogin(
+	ctx context.Context,
+	request *domain.MFALoginRequest,
+	client domain.ClientInfo,
+) (*LoginResult, error) {
+	ctx, span := tracer.Start(ctx, "AuthenticationService.CompleteMFALogin")
+	defer span.End()
+
+	challengeIDString, verifier, err := crypto.DecodeSessionToken(request.ChallengeToken)
+	if err != nil {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	challengeID, err := uuid.Parse(challengeIDString)
+	if err != nil {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	challenge, err := s.mfaRepository.GetChallengeByID(ctx, challengeID)
+	if err != nil {
 		return nil, err
 	}
 
-	session, verifier, err := s.createSessionForUser(user)
+	if challenge == nil || challenge.ExpiresAt.Before(time.Now().UTC()) {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	if !crypto.VerifyVerifier(verifier, s.keyring, challenge.KeyID, challenge.Token) {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	user, err := s.userRepository.GetByID(ctx, challenge.UserID)
 	if err != nil {
+		return nil, fmt.Errorf("failed to get user for mfa challenge: %w", err)
+	}
+
+	if s.lockoutPolicy.IsLocked(user, time.Now().UTC()) {
+		return nil, s.rejectLockedLogin(ctx, user, client)
+	}
+
+	valid, err := s.mfaService.VerifyCode(ctx, user, request.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return nil, s.handleFailedLogin(ctx, user, client, "invalid mfa code")
+	}
+
+	if err = s.mfaRepository.DeleteChallengeByID(ctx, challenge.ID); err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, user, client, "mfa")
+}
+
+func (s *authenticationService) LoginWithPasskey(
+	ctx context.Context,
+	request *domain.WebAuthnLoginFinishRequest,
+	client domain.ClientInfo,
+) (*LoginResult, error) {
+	ctx, span := tracer.Start(ctx, "AuthenticationService.LoginWithPasskey")
+	defer span.End()
+
+	user, err := s.webAuthnService.FinishLogin(ctx, *request)
+	if errors.Is(err, shared.ErrInvalidCredentials) || errors.Is(err, shared.ErrBadRequest) {
+		// The passkey didn't verify, so which user it belongs to isn't known.
+		s.recordLoginFailure(ctx, nil, client, "invalid passkey", nil)
 		return nil, err
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, user, client, "passkey")
+}
+
+// completeLogin records the successful login and issues a new session.
+// The method is how the user proved who they are and is kept in the
+// audit log.
+func (s *authenticationService) completeLogin(ctx context.Context, user *domain.User, client domain.ClientInfo, method string) (*LoginResult, error) {
+	if user.IsDisabled {
+		s.recordLoginFailure(ctx, user, client, "disabled", nil)
+		return nil, shared.ErrAccountDisabled
+	}
+
+	if s.lockoutPolicy.IsLocked(user, time.Now().UTC()) {
+		return nil, s.rejectLockedLogin(ctx, user, client)
+	}
+
+	if err := s.handleSuccessfulLogin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	session, verifier, err := s.createSessionForUser(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.Logins.WithLabelValues(metrics.LoginSuccess).Inc()
+	s.auditService.Record(
+		ctx,
+		domain.NewAuditEvent(domain.AuditLogin, user, client).
+			WithTarget(domain.AuditTargetUser, user.ID.String()).
+			WithDetails(map[string]any{"method": method}),
+	)
+
 	return &LoginResult{User: user, Session: session, RawSessionToken: verifier}, nil
 }
 
-func (s *authenticationService) handleFailedLogin(user *domain.User) error {
+func (s *authenticationService) handleFailedLogin(ctx context.Context, user *domain.User, client domain.ClientInfo, reason string) error {
 	// update login error details
 	user.FailedLoginAttempts += 1
 	now := time.Now().UTC()
 	user.LastFailedLoginAttempt = &now
 	user.UpdatedAt = now
-	user.IsDisabled = user.FailedLoginAttempts >= maximumLoginAttemptsAllowed
 
 	// update user in database
-	err := s.userRepository.UpdateBasic(user)
+	err := s.userRepository.UpdateBasic(ctx, user)
 	if err != nil {
 		return fmt.Errorf("failed to update user after failed login: %w", err)
 	}
 
 	// logging ok for critical business and security events.
-	log.Warn(fmt.Sprintf("invalid login attempt #%d for user %s", user.FailedLoginAttempts, user.Username))
+	s.logger.Warn("invalid login attempt",
+		"user_id", user.ID,
+		"username", user.Username,
+		"failed_attempts", user.FailedLoginAttempts,
+	)
+	s.recordLoginFailure(ctx, user, client, reason, map[string]any{"failedAttempts": user.FailedLoginAttempts})
+
+	if lockedUntil := s.lockoutPolicy.LockedUntil(user, now); lockedUntil != nil {
+		s.logger.Warn("user locked out",
+			"user_id", user.ID,
+			"username", user.Username,
+			"locked_until", lockedUntil.Format(time.RFC3339),
+		)
+		s.metrics.Lockouts.Inc()
+		s.auditService.Record(
+			ctx,
+			domain.NewAuditEvent(domain.AuditLockout, nil, client).
+				WithTarget(domain.AuditTargetUser, user.ID.String()).
+				WithDetails(map[string]any{
+					"username":       user.Username,
+					"failedAttempts": user.FailedLoginAttempts,
+					"lockedUntil":    lockedUntil.Format(time.RFC3339),
+				}),
+		)
+	}
+
+	return shared.ErrInvalidCredentials
+}
+
+// rejectLockedLogin records a login attempt on a locked account and returns
+// the same error as a wrong password. Telling the client about the lockout
+// would show that the username exists, so it is only logged and audited.
+func (s *authenticationService) rejectLockedLogin(ctx context.Context, user *domain.User, client domain.ClientInfo) error {
+	now := time.Now().UTC()
+
+	details := make(map[string]any)
+	if lockedUntil := s.lockoutPolicy.LockedUntil(user, now); lockedUntil != nil {
+		details["lockedUntil"] = lockedUntil.Format(time.RFC3339)
+	}
+
+	s.logger.Warn("login attempt on locked account",
+		"user_id", user.ID,
+		"username", user.Username,
+		"locked_until", details["lockedUntil"],
+	)
+	s.recordLoginFailure(ctx, user, client, "locked", details)
+
 	return shared.ErrInvalidCredentials
 }
 
-func (s *authenticationService) handleSuccessfulLogin(user *domain.User) error {
+// recordLoginFailure adds a failed login to the audit log. Nobody is
+// logged in yet, so the user is the target rather than the actor. The
+// user is nil when the username doesn't exist.
+func (s *authenticationService) recordLoginFailure(
+	ctx context.Context,
+	user *domain.User,
+	client domain.ClientInfo,
+	reason string,
+	details map[string]any,
+) {
+	if details == nil {
+		details = make(map[string]any)
+	}
+
+	details["reason"] = reason
+	s.metrics.Logins.WithLabelValues(metrics.LoginFailure).Inc()
+
+	event := domain.NewAuditEvent(domain.AuditLoginFailed, nil, client)
+	if user != nil {
+		details["username"] = user.Username
+		event.WithTarget(domain.AuditTargetUser, user.ID.String())
+	}
+
+	s.auditService.Record(ctx, event.WithDetails(details))
+}
+
+func (s *authenticationService) handleSuccessfulLogin(ctx context.Context, user *domain.User) error {
 	now := time.Now().UTC()
 	user.FailedLoginAttempts = 0
 	user.LastFailedLoginAttempt = nil
 	user.UpdatedAt = now
 	user.LastLogin = &now
 
-	err := s.userRepository.UpdateBasic(user)
+	err := s.userRepository.UpdateBasic(ctx, user)
 	if err != nil {
 		return fmt.Errorf("failed to update user after successful login: %w", err)
 	}
@@ -113,21 +368,24 @@ func (s *authenticationService) handleSuccessfulLogin(user *domain.User) error {
 	return nil
 }
 
-func (s *authenticationService) createSessionForUser(user *domain.User) (*domain.Session, []byte, error) {
+func (s *authenticationService) createSessionForUser(
+	ctx context.Context,
+	user *domain.User,
+	client domain.ClientInfo,
+) (*domain.Session, []byte, error) {
 	verifier, err := crypto.GenerateRandomBytes(32)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not generate session verifier: %w", err)
 	}
 
-	serverKey := []byte(s.hmacKey)
-	token := crypto.ComputeHMACSHA256(verifier, serverKey)
+	keyID, token := s.keyring.Sign(verifier)
 
-	session, err := domain.NewSession(user.ID, token)
+	session, err := domain.NewSession(user.ID, token, keyID, client, s.sessionDuration)
 	if err != nil {
 		return nil, nil, fmt.Errorf("session creation failed: %w", err)
 	}
 
-	err = s.sessionRepository.Create(session)
+	err = s.sessionRepository.Create(ctx, session)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to save session: %w", err)
 	}
@@ -135,10 +393,103 @@ func (s *authenticationService) createSessionForUser(user *domain.User) (*domain
 	return session, verifier, nil
 }
 
-func (s *authenticationService) Logout(session *domain.Session) error {
-	err := s.sessionRepository.DeleteByID(session.ID)
+// createMFAChallengeForUser saves a short lived challenge and returns the
+// encoded token the client must send back along with the second factor.
+func (s *authenticationService) createMFAChallengeForUser(ctx context.Context, user *domain.User) (string, error) {
+	verifier, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate challenge verifier: %w", err)
+	}
+
+	keyID, token := s.keyring.Sign(verifier)
+
+	challenge, err := domain.NewMFAChallenge(user.ID, token, keyID)
+	if err != nil {
+		return "", fmt.Errorf("mfa challenge creation failed: %w", err)
+	}
+
+	err = s.mfaRepository.CreateChallenge(ctx, challenge)
+	if err != nil {
+		return "", fmt.Errorf("failed to save mfa challenge: %w", err)
+	}
+
+	return crypto.EncodeSessionToken(challenge.ID.String(), verifier), nil
+}
+
+func (s *authenticationService) Logout(ctx context.Context, actor *domain.User, session *domain.Session, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "AuthenticationService.Logout")
+	defer span.End()
+
+	if actor == nil || session == nil {
+		return shared.ErrUnauthorized
+	}
+
+	err := s.sessionRepository.DeleteByID(ctx, session.ID)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
+
+	s.auditService.Record(
+		ctx,
+		domain.NewAuditEvent(domain.AuditLogout, actor, client).
+			WithTarget(domain.AuditTargetUser, actor.ID.String()),
+	)
+
+	return nil
+}
+
+func (s *authenticationService) ChangePassword(
+	ctx context.Context,
+	actor *domain.User,
+	session *domain.Session,
+	request domain.PasswordChangeRequest,
+	client domain.ClientInfo,
+) error {
+	ctx, span := tracer.Start(ctx, "AuthenticationService.ChangePassword")
+	defer span.End()
+
+	if actor == nil || session == nil {
+		return shared.ErrUnauthorized
+	}
+
+	match, err := s.passwordHasher.Verify(request.CurrentPassword, actor.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("password verification failed: %w", err)
+	}
+
+	if !match {
+		return fmt.Errorf("%w: the current password is incorrect", shared.ErrBadRequest)
+	}
+
+	pwHash, err := s.passwordHasher.HashPassword(request.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	actor.PasswordHash = pwHash
+	actor.MustChangePassword = false
+	actor.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdatePassword(ctx, actor)
+	if err != nil {
+		return fmt.Errorf("failed to save new password: %w", err)
+	}
+
+	err = s.sessionRepository.DeleteOthersByUserID(ctx, actor.ID, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end other sessions: %w", err)
+	}
+
+	err = s.accessTokenRepository.DeleteByUserID(ctx, actor.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	s.auditService.Record(
+		ctx,
+		domain.NewAuditEvent(domain.AuditPasswordChanged, actor, client).
+			WithTarget(domain.AuditTargetUser, actor.ID.String()),
+	)
+
 	return nil
 }
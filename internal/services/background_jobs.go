@@ -3,103 +3,189 @@ package services
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/metrics"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
 )
 
 type SessionCleanupService interface {
-	DeleteExpired() error
+	DeleteExpired(ctx context.Context) error
 }
 
 type sessionCleanupService struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	metrics *metrics.Metrics
+	logger  *slog.Logger
 }
 
-func NewSessionCleanupService(db *sqlx.DB) SessionCleanupService {
-	return &sessionCleanupService{db: db}
+func NewSessionCleanupService(db *sqlx.DB, appMetrics *metrics.Metrics, logger *slog.Logger) SessionCleanupService {
+	return &sessionCleanupService{
+		db:      db,
+		metrics: appMetrics,
+		logger:  logger,
+	}
 }
 
-// centerPad centers a string within a given width
-func centerPad(s string, width int) string {
-	if len(s) >= width {
-		return s
+// DeleteExpired deletes expired sessions along with expired MFA
+// challenges, WebAuthn ceremonies, reset tokens, access tokens and rate
+// limit buckets.
+func (s *sessionCleanupService) DeleteExpired(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "SessionCleanupService.DeleteExpired")
+	defer span.End()
+
+	query := "DELETE FROM sessions WHERE expires_at < ?"
+	result, err := s.db.ExecContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to execute delete session command: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unhandled error: %w", err)
+	}
+
+	s.metrics.SessionsCleanedUp.Add(float64(affected))
+	s.logger.Info("expired sessions deleted", "count", affected)
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM mfa_challenges WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to execute delete mfa challenge command: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM webauthn_ceremonies WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to execute delete webauthn ceremony command: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to execute delete password reset token command: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM access_tokens WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to execute delete access token command: %w", err)
 	}
-	padding := width - len(s)
-	left := padding / 2
-	right := padding - left
-	return fmt.Sprintf("%s%s%s", spaces(left), s, spaces(right))
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to execute delete rate limit bucket command: %w", err)
+	}
+
+	return nil
 }
 
-func spaces(n int) string {
-	return fmt.Sprintf("%*s", n, "")
+// RunSessionCleanupJob deletes expired sessions and other short lived
+// records every five minutes until ctx is done.
+func RunSessionCleanupJob(ctx context.Context, service SessionCleanupService, logger *slog.Logger) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	logger.Info("starting background session cleanup")
+
+	if err := service.DeleteExpired(ctx); err != nil {
+		logger.Error("session cleanup failed", "error", err)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := service.DeleteExpired(ctx); err != nil {
+				logger.Error("session cleanup failed", "error", err)
+			}
+
+		case <-ctx.Done():
+			logger.Info("background session cleanup service stopping")
+			return
+		}
+	}
 }
 
-// LogInfo prints an info line with centered dashes
-func LogInfo(msg string) {
-	now := time.Now().Format("15:04:05")
-	fmt.Printf("%s | %s | %s | %s | %s | %s | %s\n",
-		now,
-		centerPad("-", 3),  // status
-		centerPad("-", 13), // latency
-		centerPad("-", 9),  // IP
-		centerPad("-", 3),  // method
-		centerPad("-", 5),  // path
-		msg,
-	)
+type UserRetentionService interface {
+	PurgeDeleted(ctx context.Context) error
 }
 
-// LogError prints an error line with centered dashes
-func LogError(msg string, err error) {
-	now := time.Now().Format("15:04:05")
-	fmt.Printf("%s | %s | %s | %s | %s | %s | %s: %v\n",
-		now,
-		centerPad("-", 3),  // status
-		centerPad("-", 12), // latency
-		centerPad("-", 11), // IP
-		centerPad("-", 3),  // method
-		centerPad("-", 5),  // path
-		msg,
-		err,
-	)
+// retentionClient identifies the retention job in the audit log.
+var retentionClient = domain.ClientInfo{UserAgent: "mainframe-retention"}
+
+type userRetentionService struct {
+	userRepository  repository.UserRepository
+	auditService    AuditService
+	retentionPeriod time.Duration
+	logger          *slog.Logger
 }
 
-func (s *sessionCleanupService) DeleteExpired() error {
-	query := "DELETE FROM sessions WHERE expires_at < ?"
-	result, err := s.db.Exec(query, time.Now().UTC())
-	if err != nil {
-		LogError("failed to execute cleanup command", err)
-		return fmt.Errorf("failed to execute delete session command: %w", err)
+func NewUserRetentionService(
+	userRepository repository.UserRepository,
+	auditService AuditService,
+	retentionPeriod time.Duration,
+	logger *slog.Logger,
+) UserRetentionService {
+	return &userRetentionService{
+		userRepository:  userRepository,
+		auditService:    auditService,
+		retentionPeriod: retentionPeriod,
+		logger:          logger,
 	}
+}
 
-	affected, err := result.RowsAffected()
+// PurgeDeleted permanently deletes users that were soft deleted longer
+// ago than the retention period. A run that purges anyone is recorded in
+// the audit log as a single event listing the purged user ids.
+func (s *userRetentionService) PurgeDeleted(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "UserRetentionService.PurgeDeleted")
+	defer span.End()
+
+	cutoff := time.Now().UTC().Add(-s.retentionPeriod)
+
+	purged, err := s.userRepository.PurgeDeletedBefore(ctx, cutoff)
 	if err != nil {
-		LogError("failed to get number of rows affected", err)
-		return fmt.Errorf("unhandled error: %w", err)
+		return fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	s.logger.Info("deleted users purged", "count", len(purged))
+
+	if len(purged) == 0 {
+		return nil
 	}
 
-	LogInfo(fmt.Sprintf("%d expired sessions deleted", affected))
+	s.auditService.Record(
+		ctx,
+		domain.NewAuditEvent(domain.AuditRetentionPurge, domain.NewSystemActor(), retentionClient).
+			WithTarget(domain.AuditTargetUser, "").
+			WithDetails(map[string]any{
+				"userIds":         purged,
+				"deletedBefore":   cutoff.Format(time.RFC3339),
+				"retentionPeriod": s.retentionPeriod.String(),
+			}),
+	)
+
 	return nil
 }
 
-func RunSessionCleanupJob(ctx context.Context, service SessionCleanupService) {
-	ticker := time.NewTicker(5 * time.Minute)
+// RunUserRetentionJob purges users past the retention period every hour
+// until ctx is done.
+func RunUserRetentionJob(ctx context.Context, service UserRetentionService, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Hour)
 	defer ticker.Stop()
-	LogInfo("starting background session cleanup")
+	logger.Info("starting background user retention")
 
-	if err := service.DeleteExpired(); err != nil {
-		LogError("session cleanup failed", err)
+	if err := service.PurgeDeleted(ctx); err != nil {
+		logger.Error("user retention failed", "error", err)
 	}
 
 	for {
 		select {
 		case <-ticker.C:
-			if err := service.DeleteExpired(); err != nil {
-				LogError("session cleanup failed", err)
+			if err := service.PurgeDeleted(ctx); err != nil {
+				logger.Error("user retention failed", "error", err)
 			}
 
 		case <-ctx.Done():
-			LogInfo("background session cleanup service stopping")
+			logger.Info("background user retention service stopping")
 			return
 		}
 	}
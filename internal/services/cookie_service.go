@@ -7,29 +7,53 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
-	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
+// CSRFCookieName is the cookie holding the CSRF token. It is readable by
+// scripts so the frontend can copy it into the CSRFHeaderName header.
+const CSRFCookieName = "csrf_token"
+
+// CSRFHeaderName is the header unsafe requests must send the CSRF token in.
+const CSRFHeaderName = "X-CSRF-Token"
+
 type CookieService interface {
+	// ClearCookie removes the session and CSRF cookies.
 	ClearCookie(c *fiber.Ctx)
+
+	// SetCookie sets the session cookie and the CSRF cookie for the session.
 	SetCookie(c *fiber.Ctx, session *domain.Session, rawToken []byte)
+
 	ParseSessionCookie(cookie string) (sessionID string, rawToken []byte, err error)
+
+	// CSRFToken returns the CSRF token for a session. The token is derived
+	// from the session secret so it doesn't need to be stored.
+	CSRFToken(rawToken []byte) string
 }
 
-type cookieService struct{}
+type cookieService struct {
+	// secure marks cookies so browsers only send them over HTTPS.
+	secure bool
+}
 
-func NewCookieService() CookieService {
-	return &cookieService{}
+func NewCookieService(secure bool) CookieService {
+	return &cookieService{secure: secure}
 }
 
 func (s *cookieService) ClearCookie(c *fiber.Ctx) {
-	c.Cookie(getEmptyCookie())
+	c.Cookie(getEmptyCookie(s.secure))
+	c.Cookie(getEmptyCSRFCookie(s.secure))
 }
 
 func (s *cookieService) SetCookie(c *fiber.Ctx, session *domain.Session, rawToken []byte) {
 	token := base64.RawURLEncoding.EncodeToString(rawToken)
-	c.Cookie(createSessionCookie(session, token))
+	c.Cookie(createSessionCookie(session, token, s.secure))
+	c.Cookie(createCSRFCookie(session, s.CSRFToken(rawToken), s.secure))
+}
+
+func (s *cookieService) CSRFToken(rawToken []byte) string {
+	return crypto.ComputeHMACSHA256([]byte("csrf"), rawToken)
 }
 
 func (s *cookieService) ParseSessionCookie(cookie string) (sessionID string, rawToken []byte, err error) {
@@ -45,28 +69,56 @@ func (s *cookieService) ParseSessionCookie(cookie string) (sessionID string, raw
 }
 
 // createSessionCookie makes a new cookie and includes the session details.
-func createSessionCookie(session *domain.Session, rawToken string) *fiber.Cookie {
+func createSessionCookie(session *domain.Session, rawToken string, secure bool) *fiber.Cookie {
 	cookie := new(fiber.Cookie)
 	cookie.Name = "session_id"
 	cookie.Value = fmt.Sprintf("%s:%s", session.ID.String(), rawToken)
 	cookie.Expires = session.ExpiresAt
 	cookie.Path = "/"
 	cookie.HTTPOnly = true
-	cookie.Secure = shared.IsProduction() // For production
+	cookie.Secure = secure
+	cookie.SameSite = fiber.CookieSameSiteLaxMode
+	return cookie
+}
+
+// createCSRFCookie makes a cookie holding the CSRF token. It isn't HttpOnly
+// so the frontend can read it.
+func createCSRFCookie(session *domain.Session, csrfToken string, secure bool) *fiber.Cookie {
+	cookie := new(fiber.Cookie)
+	cookie.Name = CSRFCookieName
+	cookie.Value = csrfToken
+	cookie.Expires = session.ExpiresAt
+	cookie.Path = "/"
+	cookie.HTTPOnly = false
+	cookie.Secure = secure
 	cookie.SameSite = fiber.CookieSameSiteLaxMode
 	return cookie
 }
 
 // getEmptyCookie creates an empty cookie that is used to replace the existing one
 // in the browser.
-func getEmptyCookie() *fiber.Cookie {
+func getEmptyCookie(secure bool) *fiber.Cookie {
 	cookie := new(fiber.Cookie)
 	cookie.Name = "session_id"
 	cookie.Value = ""
 	cookie.Expires = time.Unix(0, 0) // Set to a time in the past
 	cookie.Path = "/"
 	cookie.HTTPOnly = true
-	cookie.Secure = shared.IsProduction()
+	cookie.Secure = secure
+	cookie.SameSite = fiber.CookieSameSiteLaxMode
+	return cookie
+}
+
+// getEmptyCSRFCookie creates an empty cookie that is used to replace the
+// CSRF cookie in the browser.
+func getEmptyCSRFCookie(secure bool) *fiber.Cookie {
+	cookie := new(fiber.Cookie)
+	cookie.Name = CSRFCookieName
+	cookie.Value = ""
+	cookie.Expires = time.Unix(0, 0)
+	cookie.Path = "/"
+	cookie.HTTPOnly = false
+	cookie.Secure = secure
 	cookie.SameSite = fiber.CookieSameSiteLaxMode
 	return cookie
 }
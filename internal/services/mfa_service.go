@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+const (
+	// mfaIssuer is the name shown for the account in authenticator apps.
+	mfaIssuer = "Mainframe"
+
+	// recoveryCodeCount is the number of recovery codes issued when MFA is enabled.
+	recoveryCodeCount = 10
+
+	// recoveryCodeAlphabet is the lowercase base32 alphabet. Its length divides
+	// evenly into a byte so every character is equally likely.
+	recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+type MFAService interface {
+	// BeginEnrollment generates a new TOTP secret for the actor. MFA is not
+	// enabled until the enrollment is confirmed with a valid code.
+	BeginEnrollment(ctx context.Context, actor *domain.User) (*domain.MFAEnrollmentResponse, error)
+
+	// ConfirmEnrollment enables MFA once the actor proves their authenticator
+	// works and returns the single-use recovery codes.
+	ConfirmEnrollment(ctx context.Context, actor *domain.User, request domain.MFAConfirmRequest) (*domain.MFAConfirmResponse, error)
+
+	// Disable turns off MFA for the actor after verifying their password and a code.
+	Disable(ctx context.Context, actor *domain.User, request domain.MFADisableRequest) error
+
+	// VerifyCode checks a TOTP code or an unused recovery code for the user.
+	// Recovery codes are consumed when they match, and a TOTP code is only
+	// accepted once.
+	VerifyCode(ctx context.Context, user *domain.User, code string) (bool, error)
+
+	// ReencryptSecrets encrypts every stored TOTP secret that doesn't use
+	// the primary key again with the primary key, so older keys can be
+	// retired. It returns how many secrets were rewritten.
+	ReencryptSecrets(ctx context.Context) (int, error)
+}
+
+func NewMFAService(
+	userRepository repository.UserRepository,
+	mfaRepository repository.MFARepository,
+	keyRepository repository.KeyRepository,
+	pwHasher domain.PasswordHasher,
+	keyring *crypto.Keyring,
+) MFAService {
+	return &mfaService{
+		userRepository: userRepository,
+		mfaRepository:  mfaRepository,
+		keyRepository:  keyRepository,
+		passwordHasher: pwHasher,
+		keyring:        keyring,
+	}
+}
+
+type mfaService struct {
+	userRepository repository.UserRepository
+	mfaRepository  repository.MFARepository
+	keyRepository  repository.KeyRepository
+	passwordHasher domain.PasswordHasher
+	keyring        *crypto.Keyring
+}
+
+func (s *mfaService) BeginEnrollment(ctx context.Context, actor *domain.User) (*domain.MFAEnrollmentResponse, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.BeginEnrollment")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	if actor.MFAEnabled {
+		return nil, fmt.Errorf("%w: multi-factor authentication is already enabled", shared.ErrBadRequest)
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	encrypted, err := s.keyring.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	actor.MFASecret = &encrypted
+	actor.MFAEnabled = false
+	actor.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdateMFA(ctx, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save totp secret: %w", err)
+	}
+
+	return &domain.MFAEnrollmentResponse{
+		Secret: secret,
+		URI:    crypto.BuildOTPAuthURI(mfaIssuer, actor.Username, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmEnrollment(ctx context.Context, actor *domain.User, request domain.MFAConfirmRequest) (*domain.MFAConfirmResponse, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.ConfirmEnrollment")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	if actor.MFAEnabled {
+		return nil, fmt.Errorf("%w: multi-factor authentication is already enabled", shared.ErrBadRequest)
+	}
+
+	if actor.MFASecret == nil {
+		return nil, fmt.Errorf("%w: multi-factor enrollment has not been started", shared.ErrBadRequest)
+	}
+
+	secret, err := s.decryptSecret(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := s.claimTOTPCode(ctx, actor, secret, request.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return nil, fmt.Errorf("%w: the code is invalid", shared.ErrBadRequest)
+	}
+
+	rawCodes, err := s.generateRecoveryCodes(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	actor.MFAEnabled = true
+	actor.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdateMFA(ctx, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	return &domain.MFAConfirmResponse{RecoveryCodes: rawCodes}, nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, actor *domain.User, request domain.MFADisableRequest) error {
+	ctx, span := tracer.Start(ctx, "MFAService.Disable")
+	defer span.End()
+
+	if actor == nil {
+		return shared.ErrUnauthorized
+	}
+
+	if !actor.MFAEnabled {
+		return fmt.Errorf("%w: multi-factor authentication is not enabled", shared.ErrBadRequest)
+	}
+
+	match, err := s.passwordHasher.Verify(request.Password, actor.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("password verification failed: %w", err)
+	}
+
+	if !match {
+		return shared.ErrInvalidCredentials
+	}
+
+	valid, err := s.VerifyCode(ctx, actor, request.Code)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return shared.ErrInvalidCredentials
+	}
+
+	actor.MFASecret = nil
+	actor.MFAEnabled = false
+	actor.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdateMFA(ctx, actor)
+	if err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	err = s.mfaRepository.DeleteRecoveryCodes(ctx, actor.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *mfaService) VerifyCode(ctx context.Context, user *domain.User, code string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.VerifyCode")
+	defer span.End()
+
+	if !user.MFAEnabled || user.MFASecret == nil {
+		return false, nil
+	}
+
+	secret, err := s.decryptSecret(user)
+	if err != nil {
+		return false, err
+	}
+
+	valid, err := s.claimTOTPCode(ctx, user, secret, code)
+	if err != nil || valid {
+		return valid, err
+	}
+
+	return s.consumeRecoveryCode(ctx, user, code)
+}
+
+// claimTOTPCode checks the code against the secret and records its time
+// step so the same code can't be used again while it is still current.
+func (s *mfaService) claimTOTPCode(ctx context.Context, user *domain.User, secret string, code string) (bool, error) {
+	step, valid := crypto.ValidateTOTPCode(secret, code, time.Now().UTC())
+	if !valid {
+		return false, nil
+	}
+
+	return s.mfaRepository.ClaimTOTPStep(ctx, user.ID, step)
+}
+
+// consumeRecoveryCode checks the code against the user's unused recovery
+// codes and deletes it if it matches so it can't be used again.
+func (s *mfaService) consumeRecoveryCode(ctx context.Context, user *domain.User, code string) (bool, error) {
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+
+	codes, err := s.mfaRepository.GetRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, recoveryCode := range codes {
+		match, err := s.passwordHasher.Verify(normalized, recoveryCode.CodeHash)
+		if err != nil {
+			return false, fmt.Errorf("recovery code verification failed: %w", err)
+		}
+
+		if match {
+			err = s.mfaRepository.DeleteRecoveryCodeByID(ctx, recoveryCode.ID)
+			if err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// generateRecoveryCodes creates a new set of recovery codes, replacing any
+// that already exist, and returns the raw codes to show to the user once.
+func (s *mfaService) generateRecoveryCodes(ctx context.Context, user *domain.User) ([]string, error) {
+	rawCodes := make([]string, recoveryCodeCount)
+	codes := make([]domain.RecoveryCode, recoveryCodeCount)
+
+	for i := range recoveryCodeCount {
+		raw, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		hash, err := s.passwordHasher.HashPassword(normalizeRecoveryCode(raw))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+
+		code, err := domain.NewRecoveryCode(user.ID, hash)
+		if err != nil {
+			return nil, err
+		}
+
+		rawCodes[i] = raw
+		codes[i] = *code
+	}
+
+	err := s.mfaRepository.ReplaceRecoveryCodes(ctx, user.ID, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawCodes, nil
+}
+
+func (s *mfaService) ReencryptSecrets(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.ReencryptSecrets")
+	defer span.End()
+
+	secrets, err := s.keyRepository.GetMFASecrets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, stored := range secrets {
+		secret, changed, err := s.keyring.Reencrypt(stored.Secret)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to re-encrypt totp secret of user %s: %w", stored.UserID, err)
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := s.keyRepository.UpdateMFASecret(ctx, stored.UserID, secret); err != nil {
+			return rewritten, err
+		}
+
+		rewritten++
+	}
+
+	return rewritten, nil
+}
+
+func (s *mfaService) decryptSecret(user *domain.User) (string, error) {
+	secret, err := s.keyring.Decrypt(*user.MFASecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// newRecoveryCode returns a random code formatted as two groups of five characters.
+func newRecoveryCode() (string, error) {
+	bytes, err := crypto.GenerateRandomBytes(10)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, b := range bytes {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+	}
+
+	return sb.String(), nil
+}
+
+// normalizeRecoveryCode removes formatting so codes can be entered with or
+// without the separator and in any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+)
+
+// fakeMFARepository keeps the last claimed TOTP step the same way the
+// users.mfa_last_step column does.
+type fakeMFARepository struct {
+	repository.MFARepository
+
+	mu       sync.Mutex
+	lastStep map[uuid.UUID]int64
+}
+
+func (r *fakeMFARepository) ClaimTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.lastStep[userID]; ok && step <= last {
+		return false, nil
+	}
+
+	r.lastStep[userID] = step
+	return true, nil
+}
+
+func (r *fakeMFARepository) GetRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]domain.RecoveryCode, error) {
+	return nil, nil
+}
+
+type mfaFixture struct {
+	service MFAService
+	user    *domain.User
+	secret  string
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+
+	keyring, err := crypto.NewSingleKeyring("test-server-key")
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("failed to generate secret: %v", err)
+	}
+
+	encrypted, err := keyring.Encrypt([]byte(secret))
+	if err != nil {
+		t.Fatalf("failed to encrypt secret: %v", err)
+	}
+
+	user := &domain.User{ID: uuid.New(), MFAEnabled: true, MFASecret: &encrypted}
+	mfaRepository := &fakeMFARepository{lastStep: make(map[uuid.UUID]int64)}
+
+	return &mfaFixture{
+		service: NewMFAService(nil, mfaRepository, nil, nil, keyring),
+		user:    user,
+		secret:  secret,
+	}
+}
+
+func (f *mfaFixture) code(t *testing.T, at time.Time) string {
+	t.Helper()
+
+	code, err := crypto.GenerateTOTPCode(f.secret, at)
+	if err != nil {
+		t.Fatalf("failed to generate code: %v", err)
+	}
+
+	return code
+}
+
+func TestVerifyCodeAcceptsACodeOnlyOnce(t *testing.T) {
+	fixture := newMFAFixture(t)
+	code := fixture.code(t, time.Now())
+
+	valid, err := fixture.service.VerifyCode(context.Background(), fixture.user, code)
+	if err != nil {
+		t.Fatalf("VerifyCode returned an error: %v", err)
+	}
+
+	if !valid {
+		t.Fatal("expected the first use of the code to be accepted")
+	}
+
+	valid, err = fixture.service.VerifyCode(context.Background(), fixture.user, code)
+	if err != nil {
+		t.Fatalf("VerifyCode returned an error: %v", err)
+	}
+
+	if valid {
+		t.Error("expected the replayed code to be rejected")
+	}
+}
+
+func TestVerifyCodeRejectsAnOlderCodeAfterANewerOne(t *testing.T) {
+	fixture := newMFAFixture(t)
+	now := time.Now()
+
+	// Both codes are inside the drift window, but once the current step
+	// has been used the previous one can't be used any more.
+	current := fixture.code(t, now)
+	previous := fixture.code(t, now.Add(-30*time.Second))
+	if current == previous {
+		t.Skip("the current and previous codes happen to be the same")
+	}
+
+	valid, err := fixture.service.VerifyCode(context.Background(), fixture.user, current)
+	if err != nil || !valid {
+		t.Fatalf("expected the current code to be accepted, got %v, %v", valid, err)
+	}
+
+	valid, err = fixture.service.VerifyCode(context.Background(), fixture.user, previous)
+	if err != nil {
+		t.Fatalf("VerifyCode returned an error: %v", err)
+	}
+
+	if valid {
+		t.Error("expected the previous step's code to be rejected after the current one was used")
+	}
+}
+
+func TestVerifyCodeRejectsWrongCodes(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	wrong := fixture.code(t, time.Now().Add(-2*time.Minute))
+	if wrong == fixture.code(t, time.Now()) {
+		t.Skip("the old code happens to match the current one")
+	}
+
+	valid, err := fixture.service.VerifyCode(context.Background(), fixture.user, wrong)
+	if err != nil {
+		t.Fatalf("VerifyCode returned an error: %v", err)
+	}
+
+	if valid {
+		t.Error("expected a code from outside the drift window to be rejected")
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/mail"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+type PasswordResetService interface {
+	// RequestReset emails a single-use reset link to every user with the
+	// email address. No error is returned when the address is unknown so
+	// callers can't use this to discover accounts. The tokens are created
+	// and the emails sent in the background so known and unknown addresses
+	// take the same time to answer.
+	RequestReset(ctx context.Context, request domain.ForgotPasswordRequest) error
+
+	// ResetPassword consumes a reset token and sets the new password.
+	// All of the user's sessions are ended and their access tokens revoked.
+	ResetPassword(ctx context.Context, request domain.ResetPasswordRequest) error
+
+	// Wait blocks until the reset emails still being prepared or sent are
+	// done, or until ctx is done. It is called at shutdown so requests that
+	// were already answered still get their email.
+	Wait(ctx context.Context) error
+}
+
+func NewPasswordResetService(
+	userRepository repository.UserRepository,
+	sessionRepository repository.SessionRepository,
+	accessTokenRepository repository.AccessTokenRepository,
+	passwordResetRepository repository.PasswordResetRepository,
+	mailer mail.Mailer,
+	pwHasher domain.PasswordHasher,
+	keyring *crypto.Keyring,
+	baseURL string,
+	logger *slog.Logger,
+) PasswordResetService {
+	return &passwordResetService{
+		userRepository:          userRepository,
+		sessionRepository:       sessionRepository,
+		accessTokenRepository:   accessTokenRepository,
+		passwordResetRepository: passwordResetRepository,
+		mailer:                  mailer,
+		passwordHasher:          pwHasher,
+		keyring:                 keyring,
+		baseURL:                 strings.TrimRight(baseURL, "/"),
+		logger:                  logger,
+	}
+}
+
+type passwordResetService struct {
+	userRepository          repository.UserRepository
+	sessionRepository       repository.SessionRepository
+	accessTokenRepository   repository.AccessTokenRepository
+	passwordResetRepository repository.PasswordResetRepository
+	mailer                  mail.Mailer
+	passwordHasher          domain.PasswordHasher
+	keyring                 *crypto.Keyring
+	baseURL                 string
+	logger                  *slog.Logger
+
+	// deliveries tracks the reset emails still being prepared or sent.
+	deliveries sync.WaitGroup
+}
+
+func (s *passwordResetService) RequestReset(ctx context.Context, request domain.ForgotPasswordRequest) error {
+	ctx, span := tracer.Start(ctx, "PasswordResetService.RequestReset")
+	defer span.End()
+
+	users, err := s.userRepository.GetAllByEmail(ctx, request.Email)
+	if err != nil {
+		return fmt.Errorf("failed to get users by email: %w", err)
+	}
+
+	if len(users) == 0 {
+		return nil
+	}
+
+	// The request must not wait on the token writes or the mail server,
+	// otherwise the response time would show which addresses have accounts.
+	deliveryCtx := context.WithoutCancel(ctx)
+
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+
+		for _, user := range users {
+			s.sendResetEmail(deliveryCtx, &user)
+		}
+	}()
+
+	return nil
+}
+
+func (s *passwordResetService) ResetPassword(ctx context.Context, request domain.ResetPasswordRequest) error {
+	ctx, span := tracer.Start(ctx, "PasswordResetService.ResetPassword")
+	defer span.End()
+
+	tokenIDString, verifier, err := crypto.DecodeSessionToken(request.Token)
+	if err != nil {
+		return fmt.Errorf("%w: the reset token is invalid or has expired", shared.ErrBadRequest)
+	}
+
+	tokenID, err := uuid.Parse(tokenIDString)
+	if err != nil {
+		return fmt.Errorf("%w: the reset token is invalid or has expired", shared.ErrBadRequest)
+	}
+
+	resetToken, err := s.passwordResetRepository.GetByID(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	if resetToken == nil || resetToken.ExpiresAt.Before(time.Now().UTC()) {
+		return fmt.Errorf("%w: the reset token is invalid or has expired", shared.ErrBadRequest)
+	}
+
+	if !crypto.VerifyVerifier(verifier, s.keyring, resetToken.KeyID, resetToken.Token) {
+		return fmt.Errorf("%w: the reset token is invalid or has expired", shared.ErrBadRequest)
+	}
+
+	user, err := s.userRepository.GetByID(ctx, resetToken.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user for reset token: %w", err)
+	}
+
+	// Consume every outstanding token for the user before changing anything
+	// so the token can't be replayed.
+	err = s.passwordResetRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	pwHash, err := s.passwordHasher.HashPassword(request.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	user.PasswordHash = pwHash
+	user.MustChangePassword = false
+	user.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdatePassword(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to save new password: %w", err)
+	}
+
+	err = s.sessionRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end user sessions: %w", err)
+	}
+
+	err = s.accessTokenRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user access tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (s *passwordResetService) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.deliveries.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendResetEmail creates a reset token for the user and emails them the
+// link. Failures are logged because the request has already been answered.
+func (s *passwordResetService) sendResetEmail(ctx context.Context, user *domain.User) {
+	token, err := s.createResetToken(ctx, user)
+	if err != nil {
+		s.logger.Error("failed to create password reset token", "user_id", user.ID, "error", err)
+		return
+	}
+
+	message := mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Mainframe password",
+		Body:    s.resetEmailBody(user, token),
+	}
+
+	if err := s.mailer.Send(message); err != nil {
+		s.logger.Error("failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+}
+
+// createResetToken replaces any outstanding reset tokens for the user with a
+// new one and returns the encoded token to include in the reset link.
+func (s *passwordResetService) createResetToken(ctx context.Context, user *domain.User) (string, error) {
+	err := s.passwordResetRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	verifier, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate reset token verifier: %w", err)
+	}
+
+	keyID, token := s.keyring.Sign(verifier)
+
+	resetToken, err := domain.NewPasswordResetToken(user.ID, token, keyID)
+	if err != nil {
+		return "", fmt.Errorf("reset token creation failed: %w", err)
+	}
+
+	err = s.passwordResetRepository.Create(ctx, resetToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to save reset token: %w", err)
+	}
+
+	return crypto.EncodeSessionToken(resetToken.ID.String(), verifier), nil
+}
+
+func (s *passwordResetService) resetEmailBody(user *domain.User, token string) string {
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.baseURL, url.QueryEscape(token))
+
+	return fmt.Sprintf(
+		"Hi %s,\n\n"+
+			"Someone asked to reset the password for the Mainframe account %q.\n"+
+			"If that was you, use the link below within 30 minutes to choose a new password:\n\n"+
+			"%s\n\n"+
+			"If you didn't ask for this, you can ignore this email.\n",
+		user.FirstName,
+		user.Username,
+		link,
+	)
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/mail"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// fakeMailer records the messages it is asked to send. When release is set
+// each send waits for it to be closed first.
+type fakeMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+	release  chan struct{}
+	err      error
+}
+
+func (m *fakeMailer) Send(message mail.Message) error {
+	if m.release != nil {
+		<-m.release
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	return m.err
+}
+
+func (m *fakeMailer) sent() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]mail.Message(nil), m.messages...)
+}
+
+type fakeResetUserRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users []domain.User
+}
+
+func (r *fakeResetUserRepository) GetAllByEmail(ctx context.Context, email string) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make([]domain.User, 0)
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (r *fakeResetUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+
+	return nil, shared.ErrNotFound
+}
+
+func (r *fakeResetUserRepository) UpdatePassword(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			r.users[i].PasswordHash = user.PasswordHash
+			return nil
+		}
+	}
+
+	return shared.ErrNotFound
+}
+
+type fakePasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]domain.PasswordResetToken
+}
+
+func (r *fakePasswordResetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &token, nil
+}
+
+func (r *fakePasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *fakePasswordResetRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+
+	return nil
+}
+
+type fakeResetSessionRepository struct {
+	repository.SessionRepository
+
+	mu    sync.Mutex
+	ended []uuid.UUID
+}
+
+func (r *fakeResetSessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ended = append(r.ended, userID)
+	return nil
+}
+
+type fakeResetAccessTokenRepository struct {
+	repository.AccessTokenRepository
+
+	mu      sync.Mutex
+	revoked []uuid.UUID
+}
+
+func (r *fakeResetAccessTokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakePasswordHasher struct {
+	domain.PasswordHasher
+}
+
+func (fakePasswordHasher) HashPassword(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+type passwordResetFixture struct {
+	service  *passwordResetService
+	users    *fakeResetUserRepository
+	tokens   *fakePasswordResetRepository
+	sessions *fakeResetSessionRepository
+	access   *fakeResetAccessTokenRepository
+	mailer   *fakeMailer
+}
+
+func newPasswordResetFixture(t *testing.T, users ...domain.User) *passwordResetFixture {
+	t.Helper()
+
+	keyring, err := crypto.NewSingleKeyring("test-server-key")
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	fixture := &passwordResetFixture{
+		users:    &fakeResetUserRepository{users: users},
+		tokens:   &fakePasswordResetRepository{tokens: map[uuid.UUID]domain.PasswordResetToken{}},
+		sessions: &fakeResetSessionRepository{},
+		access:   &fakeResetAccessTokenRepository{},
+		mailer:   &fakeMailer{},
+	}
+
+	fixture.service = NewPasswordResetService(
+		fixture.users,
+		fixture.sessions,
+		fixture.access,
+		fixture.tokens,
+		fixture.mailer,
+		fakePasswordHasher{},
+		keyring,
+		"https://mainframe.example.com/",
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	).(*passwordResetService)
+
+	return fixture
+}
+
+func newResetTestUser(username string, email string) domain.User {
+	return domain.User{
+		ID:        uuid.New(),
+		Username:  username,
+		FirstName: "Test",
+		Email:     email,
+	}
+}
+
+// tokenFromMessage returns the reset token from the link in the email.
+func tokenFromMessage(t *testing.T, message mail.Message) string {
+	t.Helper()
+
+	_, rest, found := strings.Cut(message.Body, "https://mainframe.example.com/reset-password?token=")
+	if !found {
+		t.Fatalf("reset link not found in email body:\n%s", message.Body)
+	}
+
+	encoded, _, _ := strings.Cut(rest, "\n")
+	token, err := url.QueryUnescape(encoded)
+	if err != nil {
+		t.Fatalf("failed to unescape reset token: %v", err)
+	}
+
+	return token
+}
+
+func TestRequestResetEmailsEveryUserWithTheAddress(t *testing.T) {
+	first := newResetTestUser("first", "shared@example.com")
+	second := newResetTestUser("second", "shared@example.com")
+	other := newResetTestUser("other", "other@example.com")
+	fixture := newPasswordResetFixture(t, first, second, other)
+
+	err := fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "shared@example.com"})
+	if err != nil {
+		t.Fatalf("RequestReset returned an error: %v", err)
+	}
+
+	if err := fixture.service.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned an error: %v", err)
+	}
+
+	sent := fixture.mailer.sent()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(sent))
+	}
+
+	for _, message := range sent {
+		if message.To != "shared@example.com" {
+			t.Errorf("email sent to %q, want shared@example.com", message.To)
+		}
+	}
+
+	if len(fixture.tokens.tokens) != 2 {
+		t.Errorf("expected 2 saved reset tokens, got %d", len(fixture.tokens.tokens))
+	}
+}
+
+func TestRequestResetSendsNothingForUnknownAddress(t *testing.T) {
+	fixture := newPasswordResetFixture(t, newResetTestUser("known", "known@example.com"))
+
+	err := fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "unknown@example.com"})
+	if err != nil {
+		t.Fatalf("RequestReset returned an error: %v", err)
+	}
+
+	if err := fixture.service.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned an error: %v", err)
+	}
+
+	if sent := fixture.mailer.sent(); len(sent) != 0 {
+		t.Errorf("expected no emails, got %d", len(sent))
+	}
+
+	if len(fixture.tokens.tokens) != 0 {
+		t.Errorf("expected no saved reset tokens, got %d", len(fixture.tokens.tokens))
+	}
+}
+
+func TestRequestResetDoesNotWaitForTheMailer(t *testing.T) {
+	fixture := newPasswordResetFixture(t, newResetTestUser("known", "known@example.com"))
+	fixture.mailer.release = make(chan struct{})
+
+	returned := make(chan error, 1)
+	go func() {
+		returned <- fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "known@example.com"})
+	}()
+
+	select {
+	case err := <-returned:
+		if err != nil {
+			t.Fatalf("RequestReset returned an error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RequestReset waited for the mailer")
+	}
+
+	close(fixture.mailer.release)
+	if err := fixture.service.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned an error: %v", err)
+	}
+
+	if sent := fixture.mailer.sent(); len(sent) != 1 {
+		t.Errorf("expected 1 email, got %d", len(sent))
+	}
+}
+
+func TestRequestResetIgnoresMailerFailures(t *testing.T) {
+	fixture := newPasswordResetFixture(t, newResetTestUser("known", "known@example.com"))
+	fixture.mailer.err = errors.New("mail server unavailable")
+
+	err := fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "known@example.com"})
+	if err != nil {
+		t.Fatalf("RequestReset returned an error: %v", err)
+	}
+
+	if err := fixture.service.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned an error: %v", err)
+	}
+}
+
+func TestResetPasswordWithEmailedToken(t *testing.T) {
+	user := newResetTestUser("known", "known@example.com")
+	fixture := newPasswordResetFixture(t, user)
+
+	err := fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "known@example.com"})
+	if err != nil {
+		t.Fatalf("RequestReset returned an error: %v", err)
+	}
+
+	if err := fixture.service.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned an error: %v", err)
+	}
+
+	sent := fixture.mailer.sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(sent))
+	}
+
+	request := domain.ResetPasswordRequest{Token: tokenFromMessage(t, sent[0]), NewPassword: "N3w!!Password99"}
+
+	if err := fixture.service.ResetPassword(context.Background(), request); err != nil {
+		t.Fatalf("ResetPassword returned an error: %v", err)
+	}
+
+	updated, _ := fixture.users.GetByID(context.Background(), user.ID)
+	if updated.PasswordHash != "hashed:N3w!!Password99" {
+		t.Errorf("password hash is %q, want the new password's hash", updated.PasswordHash)
+	}
+
+	if len(fixture.sessions.ended) != 1 || fixture.sessions.ended[0] != user.ID {
+		t.Errorf("expected the user's sessions to be ended, got %v", fixture.sessions.ended)
+	}
+
+	if len(fixture.access.revoked) != 1 || fixture.access.revoked[0] != user.ID {
+		t.Errorf("expected the user's access tokens to be revoked, got %v", fixture.access.revoked)
+	}
+
+	// The token is single use.
+	err = fixture.service.ResetPassword(context.Background(), request)
+	if !errors.Is(err, shared.ErrBadRequest) {
+		t.Errorf("reusing the token returned %v, want ErrBadRequest", err)
+	}
+}
+
+func TestResetPasswordRejectsTamperedToken(t *testing.T) {
+	fixture := newPasswordResetFixture(t, newResetTestUser("known", "known@example.com"))
+
+	err := fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "known@example.com"})
+	if err != nil {
+		t.Fatalf("RequestReset returned an error: %v", err)
+	}
+
+	if err := fixture.service.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned an error: %v", err)
+	}
+
+	sent := fixture.mailer.sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(sent))
+	}
+
+	tokenID, _, err := crypto.DecodeSessionToken(tokenFromMessage(t, sent[0]))
+	if err != nil {
+		t.Fatalf("failed to decode reset token: %v", err)
+	}
+
+	forged := crypto.EncodeSessionToken(tokenID, []byte("not the emailed verifier"))
+
+	err = fixture.service.ResetPassword(context.Background(), domain.ResetPasswordRequest{Token: forged, NewPassword: "N3w!!Password99"})
+	if !errors.Is(err, shared.ErrBadRequest) {
+		t.Errorf("forged token returned %v, want ErrBadRequest", err)
+	}
+}
+
+func TestWaitGivesUpWhenTheContextIsDone(t *testing.T) {
+	fixture := newPasswordResetFixture(t, newResetTestUser("known", "known@example.com"))
+	fixture.mailer.release = make(chan struct{})
+	defer close(fixture.mailer.release)
+
+	err := fixture.service.RequestReset(context.Background(), domain.ForgotPasswordRequest{Email: "known@example.com"})
+	if err != nil {
+		t.Fatalf("RequestReset returned an error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := fixture.service.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait returned %v, want context.DeadlineExceeded", err)
+	}
+}
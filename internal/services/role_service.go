@@ -1,27 +1,271 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
 	"github.com/th3oth3rjak3/mainframe/internal/repository"
 	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
 type RoleService interface {
-	GetAllRoles(user *domain.User) ([]domain.Role, error)
+	// GetAllRoles returns every role with its permissions.
+	GetAllRoles(ctx context.Context, user *domain.User) ([]domain.Role, error)
+
+	// GetRoleByID returns one role with its permissions.
+	GetRoleByID(ctx context.Context, actor *domain.User, roleID uuid.UUID) (*domain.Role, error)
+
+	// CreateRole makes a new custom role and returns its ID. Actors other
+	// than Administrators can only include permissions they have.
+	CreateRole(ctx context.Context, actor *domain.User, request domain.RoleCreate, client domain.ClientInfo) (uuid.UUID, error)
+
+	// UpdateRole renames a role, changes its description and replaces its
+	// permissions. Built-in roles can't be renamed and the permissions of
+	// the Administrator role can't be changed. Actors other than
+	// Administrators can't edit roles they have, and can only edit roles
+	// whose old and new permissions they all have.
+	UpdateRole(ctx context.Context, actor *domain.User, roleID uuid.UUID, request domain.RoleUpdate, client domain.ClientInfo) error
+
+	// DeleteRole removes a custom role. Built-in roles can't be deleted.
+	// When users still have the role, reassignTo must name the role they
+	// are moved to instead, which the actor must be able to grant.
+	//
+	// Creating, updating and deleting roles is recorded in the audit log.
+	DeleteRole(ctx context.Context, actor *domain.User, roleID uuid.UUID, reassignTo *uuid.UUID, client domain.ClientInfo) error
 }
 
 type roleService struct {
 	roleRepository repository.RoleRepository
+	auditService   AuditService
 }
 
-func NewRoleService(roleRepository repository.RoleRepository) RoleService {
-	return &roleService{roleRepository: roleRepository}
+func NewRoleService(roleRepository repository.RoleRepository, auditService AuditService) RoleService {
+	return &roleService{roleRepository: roleRepository, auditService: auditService}
 }
 
-func (r *roleService) GetAllRoles(user *domain.User) ([]domain.Role, error) {
-	if !user.HasRole(domain.Administrator) {
+func (r *roleService) GetAllRoles(ctx context.Context, user *domain.User) ([]domain.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.GetAllRoles")
+	defer span.End()
+
+	if user == nil || !user.Can(domain.PermissionRolesRead) {
 		return nil, shared.ErrForbidden
 	}
 
-	return r.roleRepository.GetAll()
+	return r.roleRepository.GetAll(ctx)
+}
+
+func (r *roleService) GetRoleByID(ctx context.Context, actor *domain.User, roleID uuid.UUID) (*domain.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.GetRoleByID")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionRolesRead) {
+		return nil, shared.ErrForbidden
+	}
+
+	role, err := r.roleRepository.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role by ID: %w", err)
+	}
+
+	return role, nil
+}
+
+func (r *roleService) CreateRole(ctx context.Context, actor *domain.User, request domain.RoleCreate, client domain.ClientInfo) (uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.CreateRole")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionRolesWrite) {
+		return uuid.UUID{}, shared.ErrForbidden
+	}
+
+	if err := r.checkNameAvailable(ctx, request.Name, uuid.Nil); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	role, err := domain.NewRole(request.Name, request.Description, uniquePermissions(request.Permissions))
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if err := checkCanGrant(actor, role); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	err = r.roleRepository.Create(ctx, role)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	r.recordAudit(ctx, domain.AuditRoleCreated, actor, client, role.ID, map[string]any{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+	})
+
+	return role.ID, nil
+}
+
+func (r *roleService) UpdateRole(ctx context.Context, actor *domain.User, roleID uuid.UUID, request domain.RoleUpdate, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "RoleService.UpdateRole")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionRolesWrite) {
+		return shared.ErrForbidden
+	}
+
+	role, err := r.roleRepository.GetByID(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role by ID: %w", err)
+	}
+
+	// Editing a role you have would let you grant yourself whatever
+	// permissions you can grant others.
+	if !actor.HasRole(domain.Administrator) && slices.ContainsFunc(actor.Roles, func(held domain.Role) bool {
+		return held.ID == role.ID
+	}) {
+		return fmt.Errorf("%w: only an Administrator can edit a role you have", shared.ErrForbidden)
+	}
+
+	if err := checkCanGrant(actor, role); err != nil {
+		return err
+	}
+
+	if role.IsBuiltIn && request.Name != role.Name {
+		return fmt.Errorf("%w: built-in roles can't be renamed", shared.ErrBadRequest)
+	}
+
+	permissions := uniquePermissions(request.Permissions)
+	if role.Name == domain.Administrator && !slices.Equal(permissions, uniquePermissions(domain.Permissions)) {
+		return fmt.Errorf("%w: the Administrator role must keep every permission", shared.ErrBadRequest)
+	}
+
+	if err := checkCanGrant(actor, &domain.Role{Name: request.Name, Permissions: permissions}); err != nil {
+		return err
+	}
+
+	if err := r.checkNameAvailable(ctx, request.Name, role.ID); err != nil {
+		return err
+	}
+
+	role.Name = request.Name
+	role.Description = request.Description
+	role.Permissions = permissions
+
+	err = r.roleRepository.Update(ctx, role)
+	if err != nil {
+		return fmt.Errorf("failed to save updated role: %w", err)
+	}
+
+	r.recordAudit(ctx, domain.AuditRoleUpdated, actor, client, role.ID, map[string]any{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+	})
+
+	return nil
+}
+
+func (r *roleService) DeleteRole(ctx context.Context, actor *domain.User, roleID uuid.UUID, reassignTo *uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "RoleService.DeleteRole")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionRolesWrite) {
+		return shared.ErrForbidden
+	}
+
+	role, err := r.roleRepository.GetByID(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role by ID: %w", err)
+	}
+
+	if role.IsBuiltIn {
+		return fmt.Errorf("%w: built-in roles can't be deleted", shared.ErrBadRequest)
+	}
+
+	if reassignTo != nil {
+		if *reassignTo == role.ID {
+			return fmt.Errorf("%w: users can't be reassigned to the role being deleted", shared.ErrBadRequest)
+		}
+
+		reassignRole, err := r.roleRepository.GetByID(ctx, *reassignTo)
+		if errors.Is(err, shared.ErrNotFound) {
+			return fmt.Errorf("%w: the role to reassign users to doesn't exist", shared.ErrBadRequest)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get role by ID: %w", err)
+		}
+
+		if err := checkCanGrant(actor, reassignRole); err != nil {
+			return err
+		}
+	} else {
+		count, err := r.roleRepository.CountUsers(ctx, role.ID)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("%w: reassign the %d user(s) with this role to another role to delete it", shared.ErrRoleInUse, count)
+		}
+	}
+
+	err = r.roleRepository.Delete(ctx, role.ID, reassignTo)
+	if err != nil {
+		return err
+	}
+
+	r.recordAudit(ctx, domain.AuditRoleDeleted, actor, client, role.ID, map[string]any{"reassignTo": reassignTo})
+
+	return nil
+}
+
+// recordAudit adds a change the actor made to a role to the audit log.
+func (r *roleService) recordAudit(
+	ctx context.Context,
+	action string,
+	actor *domain.User,
+	client domain.ClientInfo,
+	roleID uuid.UUID,
+	details map[string]any,
+) {
+	event := domain.NewAuditEvent(action, actor, client).
+		WithTarget(domain.AuditTargetRole, roleID.String()).
+		WithDetails(details)
+
+	r.auditService.Record(ctx, event)
+}
+
+// checkNameAvailable returns an error when a role other than the one with
+// the given ID already uses the name.
+func (r *roleService) checkNameAvailable(ctx context.Context, name string, roleID uuid.UUID) error {
+	existing, err := r.roleRepository.GetByName(ctx, name)
+	if errors.Is(err, shared.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get role by name: %w", err)
+	}
+
+	if existing.ID != roleID {
+		return shared.ErrRoleNameTaken
+	}
+
+	return nil
+}
+
+// uniquePermissions returns a sorted copy of the permissions without duplicates.
+func uniquePermissions(permissions []string) []string {
+	result := slices.Clone(permissions)
+	if result == nil {
+		result = make([]string, 0)
+	}
+
+	slices.Sort(result)
+	return slices.Compact(result)
 }
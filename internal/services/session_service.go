@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+type SessionService interface {
+	// GetOwnSessions returns the actor's active sessions. The session making
+	// the request is marked as current.
+	GetOwnSessions(ctx context.Context, actor *domain.User, current *domain.Session) ([]domain.SessionRead, error)
+
+	// RevokeOwnSession ends one of the actor's sessions.
+	RevokeOwnSession(ctx context.Context, actor *domain.User, sessionID uuid.UUID) error
+
+	// RevokeOtherSessions ends every session the actor has except the current one.
+	RevokeOtherSessions(ctx context.Context, actor *domain.User, current *domain.Session) error
+
+	// GetUserSessions returns the active sessions of any user.
+	// The actor needs the users:read permission.
+	GetUserSessions(ctx context.Context, actor *domain.User, userID uuid.UUID) ([]domain.SessionRead, error)
+
+	// RevokeUserSession ends one session of any user. The actor needs
+	// the users:write permission, and must be able to grant the
+	// Administrator role to end an Administrator's sessions.
+	RevokeUserSession(ctx context.Context, actor *domain.User, userID uuid.UUID, sessionID uuid.UUID) error
+
+	// RevokeAllUserSessions ends every session of any user, with the
+	// same permission rules as RevokeUserSession.
+	RevokeAllUserSessions(ctx context.Context, actor *domain.User, userID uuid.UUID) error
+}
+
+func NewSessionService(
+	sessionRepository repository.SessionRepository,
+	userRepository repository.UserRepository,
+) SessionService {
+	return &sessionService{
+		sessionRepository: sessionRepository,
+		userRepository:    userRepository,
+	}
+}
+
+type sessionService struct {
+	sessionRepository repository.SessionRepository
+	userRepository    repository.UserRepository
+}
+
+func (s *sessionService) GetOwnSessions(ctx context.Context, actor *domain.User, current *domain.Session) ([]domain.SessionRead, error) {
+	ctx, span := tracer.Start(ctx, "SessionService.GetOwnSessions")
+	defer span.End()
+
+	if actor == nil || current == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	return s.getSessions(ctx, actor.ID, current.ID)
+}
+
+func (s *sessionService) RevokeOwnSession(ctx context.Context, actor *domain.User, sessionID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "SessionService.RevokeOwnSession")
+	defer span.End()
+
+	if actor == nil {
+		return shared.ErrUnauthorized
+	}
+
+	return s.revokeSession(ctx, actor.ID, sessionID)
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, actor *domain.User, current *domain.Session) error {
+	ctx, span := tracer.Start(ctx, "SessionService.RevokeOtherSessions")
+	defer span.End()
+
+	if actor == nil || current == nil {
+		return shared.ErrUnauthorized
+	}
+
+	err := s.sessionRepository.DeleteOthersByUserID(ctx, actor.ID, current.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end other sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sessionService) GetUserSessions(ctx context.Context, actor *domain.User, userID uuid.UUID) ([]domain.SessionRead, error) {
+	ctx, span := tracer.Start(ctx, "SessionService.GetUserSessions")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersRead) {
+		return nil, shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	return s.getSessions(ctx, user.ID, uuid.Nil)
+}
+
+func (s *sessionService) RevokeUserSession(ctx context.Context, actor *domain.User, userID uuid.UUID, sessionID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "SessionService.RevokeUserSession")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	return s.revokeSession(ctx, user.ID, sessionID)
+}
+
+func (s *sessionService) RevokeAllUserSessions(ctx context.Context, actor *domain.User, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "SessionService.RevokeAllUserSessions")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	err = s.sessionRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end user sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sessionService) getSessions(ctx context.Context, userID uuid.UUID, currentSessionID uuid.UUID) ([]domain.SessionRead, error) {
+	sessions, err := s.sessionRepository.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionList := make([]domain.SessionRead, len(sessions))
+	for idx, session := range sessions {
+		sessionList[idx] = domain.NewSessionRead(&session, currentSessionID)
+	}
+
+	return sessionList, nil
+}
+
+// revokeSession deletes the session when it belongs to the user. Sessions
+// owned by someone else are reported as not found.
+func (s *sessionService) revokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != userID {
+		return shared.ErrNotFound
+	}
+
+	err = s.sessionRepository.DeleteByID(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// setupTokenBytes is the number of random bytes in a setup token.
+const setupTokenBytes = 32
+
+type SetupService interface {
+	// IsRequired reports whether the first administrator still needs to be
+	// created, which is true until any user exists.
+	IsRequired(ctx context.Context) (bool, error)
+
+	// IssueToken creates the one-time setup token when setup is required.
+	// It returns an empty string when setup has already been completed.
+	IssueToken(ctx context.Context) (string, error)
+
+	// CreateAdministrator creates the first administrator using the setup
+	// token. The token can't be used again afterwards.
+	CreateAdministrator(ctx context.Context, request domain.SetupRequest, client domain.ClientInfo) (uuid.UUID, error)
+
+	// CreateInitialAdministrator creates the first administrator without a
+	// setup token. It is meant for the command line, where having access to
+	// the database already proves who is asking.
+	CreateInitialAdministrator(ctx context.Context, request domain.UserCreate, client domain.ClientInfo) (uuid.UUID, error)
+}
+
+type setupService struct {
+	userRepository repository.UserRepository
+	roleRepository repository.RoleRepository
+	passwordHasher domain.PasswordHasher
+	auditService   AuditService
+
+	mu    sync.Mutex
+	token string
+}
+
+func NewSetupService(
+	userRepository repository.UserRepository,
+	roleRepository repository.RoleRepository,
+	pwHasher domain.PasswordHasher,
+	auditService AuditService,
+) SetupService {
+	return &setupService{
+		userRepository: userRepository,
+		roleRepository: roleRepository,
+		passwordHasher: pwHasher,
+		auditService:   auditService,
+	}
+}
+
+func (s *setupService) IsRequired(ctx context.Context) (bool, error) {
+	ctx, span := tracer.Start(ctx, "SetupService.IsRequired")
+	defer span.End()
+
+	count, err := s.userRepository.Count(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+func (s *setupService) IssueToken(ctx context.Context) (string, error) {
+	ctx, span := tracer.Start(ctx, "SetupService.IssueToken")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	required, err := s.IsRequired(ctx)
+	if err != nil || !required {
+		return "", err
+	}
+
+	tokenBytes, err := crypto.GenerateRandomBytes(setupTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate setup token: %w", err)
+	}
+
+	s.token = base64.RawURLEncoding.EncodeToString(tokenBytes)
+	return s.token, nil
+}
+
+func (s *setupService) CreateAdministrator(ctx context.Context, request domain.SetupRequest, client domain.ClientInfo) (uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "SetupService.CreateAdministrator")
+	defer span.End()
+
+	if err := request.Validate(); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == "" || subtle.ConstantTimeCompare([]byte(s.token), []byte(request.Token)) != 1 {
+		return uuid.UUID{}, fmt.Errorf("%w: the setup token is invalid", shared.ErrForbidden)
+	}
+
+	id, err := s.createAdministrator(ctx, request.UserCreate, client, "token")
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	s.token = ""
+	return id, nil
+}
+
+func (s *setupService) CreateInitialAdministrator(ctx context.Context, request domain.UserCreate, client domain.ClientInfo) (uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "SetupService.CreateInitialAdministrator")
+	defer span.End()
+
+	if err := request.Validate(); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createAdministrator(ctx, request, client, "cli")
+}
+
+// createAdministrator creates a user with the Administrator and Basic User
+// roles as long as no users exist yet and records how setup was completed
+// in the audit log. The caller must hold the lock.
+func (s *setupService) createAdministrator(
+	ctx context.Context,
+	request domain.UserCreate,
+	client domain.ClientInfo,
+	method string,
+) (uuid.UUID, error) {
+	required, err := s.IsRequired(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if !required {
+		s.token = ""
+		return uuid.UUID{}, fmt.Errorf("%w: setup has already been completed", shared.ErrForbidden)
+	}
+
+	pwHash, err := s.passwordHasher.HashPassword(request.Password)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	roles := make([]domain.Role, 0, 2)
+	for _, name := range []string{domain.Administrator, domain.BasicUser} {
+		role, err := s.roleRepository.GetByName(ctx, name)
+		if err != nil {
+			return uuid.UUID{}, fmt.Errorf("failed to get the %s role: %w", name, err)
+		}
+
+		roles = append(roles, *role)
+	}
+
+	newUser, err := domain.NewUser(request.Username, request.Email, request.FirstName, request.LastName, pwHash, roles)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	err = s.userRepository.Create(ctx, newUser)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	s.auditService.Record(
+		ctx,
+		domain.NewAuditEvent(domain.AuditSetupCompleted, nil, client).
+			WithTarget(domain.AuditTargetUser, newUser.ID.String()).
+			WithDetails(map[string]any{"username": newUser.Username, "method": method}),
+	)
+
+	return newUser.ID, nil
+}
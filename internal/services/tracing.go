@@ -0,0 +1,6 @@
+package services
+
+import "github.com/th3oth3rjak3/mainframe/internal/tracing"
+
+// tracer starts a span for each service method.
+var tracer = tracing.Tracer("github.com/th3oth3rjak3/mainframe/internal/services")
@@ -1,64 +1,133 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/crypto"
 	"github.com/th3oth3rjak3/mainframe/internal/domain"
 	"github.com/th3oth3rjak3/mainframe/internal/repository"
 	"github.com/th3oth3rjak3/mainframe/internal/shared"
 )
 
 type UserService interface {
-	// GetAll returns all users or an error. There aren't assumed
-	// to be more than 25 users for this application, so paging
-	// isn't necessary. The supplied user is the one performing the
-	// request and must have the correct role to access this resource.
-	GetAll(actor *domain.User) ([]domain.UserRead, error)
+	// GetAll returns one page of the users matching the query along
+	// with the total number of matches. The supplied user is the one
+	// performing the request and must have the users:read permission.
+	GetAll(ctx context.Context, actor *domain.User, query domain.UserQuery) (*domain.Page[domain.UserRead], error)
 
 	// GetByID gets a user by their ID. If the user is not found
 	// no error will be returned and the user will be nil.
-	// The supplied user must have the correct role to access this
-	// protected resource.
-	GetByID(actor *domain.User, userID uuid.UUID) (*domain.UserRead, error)
+	// The supplied user must have the users:read permission. Every
+	// other method needs users:write, and can only change an
+	// Administrator when the actor could grant that role. Changes are
+	// recorded in the audit log along with the client that made them.
+	GetByID(ctx context.Context, actor *domain.User, userID uuid.UUID) (*domain.UserRead, error)
 
 	// Create makes a new user from the provided request. The ID of the new
 	// user will be returned upon success.
-	Create(actor *domain.User, request domain.UserCreate) (uuid.UUID, error)
+	Create(ctx context.Context, actor *domain.User, request domain.UserCreate, client domain.ClientInfo) (uuid.UUID, error)
 
 	// Update saves changes to a user based on the request.
-	Update(actor *domain.User, userID uuid.UUID, request domain.UserUpdate) error
-
-	// Delete removes an existing user from the system and cascade deletes all
-	// associated data unrecoverably.
-	Delete(actor *domain.User, userID uuid.UUID) error
+	Update(ctx context.Context, actor *domain.User, userID uuid.UUID, request domain.UserUpdate, client domain.ClientInfo) error
+
+	// Delete soft deletes a user. They are hidden and can't log in, but
+	// their data is kept until they are purged. All of the user's
+	// sessions are ended.
+	Delete(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error
+
+	// Restore brings back a soft deleted user.
+	Restore(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error
+
+	// Purge permanently removes a soft deleted user and cascade deletes
+	// all associated data unrecoverably.
+	Purge(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error
+
+	// ResetPassword replaces the user's password with a temporary one that
+	// must be changed at their next login. All of the user's sessions are
+	// ended and their access tokens revoked.
+	// An Administrator can only be reset by someone who could grant that role.
+	ResetPassword(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) (*domain.PasswordResetResponse, error)
+
+	// Unlock clears the failed login attempts so an automatically locked
+	// account can log in again right away. It doesn't enable a disabled account.
+	Unlock(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error
+
+	// Disable stops the user from logging in until an administrator enables
+	// them again. All of the user's sessions are ended. An Administrator can
+	// only be disabled by someone who could grant that role, and the last
+	// enabled Administrator can't be disabled.
+	Disable(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error
+
+	// Enable lets a disabled user log in again.
+	Enable(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error
+
+	// SetRoles replaces every role the user has. Administrators can't
+	// remove their own Administrator role or the last Administrator.
+	// Unless the actor is an Administrator, they must already hold every
+	// permission of each role they grant.
+	SetRoles(ctx context.Context, actor *domain.User, userID uuid.UUID, request domain.UserRolesUpdate, client domain.ClientInfo) error
+
+	// AddRole grants a role to the user. Unless the actor is an
+	// Administrator, they must already hold every permission of the role.
+	AddRole(ctx context.Context, actor *domain.User, userID uuid.UUID, roleID uuid.UUID, client domain.ClientInfo) error
+
+	// GetProfile returns the actor's own details.
+	GetProfile(ctx context.Context, actor *domain.User) (*domain.UserRead, error)
+
+	// UpdateProfile saves changes the actor makes to their own details.
+	// Changing the email address requires the current password.
+	UpdateProfile(ctx context.Context, actor *domain.User, request domain.ProfileUpdate, client domain.ClientInfo) error
+
+	// RemoveRole revokes a role from the user. Administrators can't
+	// remove their own Administrator role or the last Administrator.
+	RemoveRole(ctx context.Context, actor *domain.User, userID uuid.UUID, roleID uuid.UUID, client domain.ClientInfo) error
 }
 
+// temporaryPasswordLength is the length of passwords generated by an admin reset.
+const temporaryPasswordLength = 16
+
 func NewUserService(
 	userRepository repository.UserRepository,
 	roleRepository repository.RoleRepository,
+	sessionRepository repository.SessionRepository,
+	accessTokenRepository repository.AccessTokenRepository,
 	pwHasher domain.PasswordHasher,
+	auditService AuditService,
 ) UserService {
 	return &userService{
-		userRepository: userRepository,
-		roleRepository: roleRepository,
-		passwordHasher: pwHasher,
+		userRepository:        userRepository,
+		roleRepository:        roleRepository,
+		sessionRepository:     sessionRepository,
+		accessTokenRepository: accessTokenRepository,
+		passwordHasher:        pwHasher,
+		auditService:          auditService,
 	}
 }
 
 type userService struct {
-	userRepository repository.UserRepository
-	roleRepository repository.RoleRepository
-	passwordHasher domain.PasswordHasher
+	userRepository        repository.UserRepository
+	roleRepository        repository.RoleRepository
+	sessionRepository     repository.SessionRepository
+	accessTokenRepository repository.AccessTokenRepository
+	passwordHasher        domain.PasswordHasher
+	auditService          AuditService
 }
 
-func (s *userService) GetAll(actor *domain.User) ([]domain.UserRead, error) {
-	if actor == nil || !actor.HasRole(domain.Administrator) {
+func (s *userService) GetAll(ctx context.Context, actor *domain.User, query domain.UserQuery) (*domain.Page[domain.UserRead], error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetAll")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersRead) {
 		return nil, shared.ErrForbidden
 	}
 
-	users, err := s.userRepository.GetAll()
+	users, total, err := s.userRepository.Search(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all users: %w", err)
 	}
@@ -69,15 +138,18 @@ func (s *userService) GetAll(actor *domain.User) ([]domain.UserRead, error) {
 		userList[idx] = domain.NewUserRead(&user)
 	}
 
-	return userList, nil
+	return domain.NewPage(userList, total, query.Page, query.PageSize), nil
 }
 
-func (s *userService) GetByID(actor *domain.User, userID uuid.UUID) (*domain.UserRead, error) {
-	if actor == nil || !actor.HasRole(domain.Administrator) {
+func (s *userService) GetByID(ctx context.Context, actor *domain.User, userID uuid.UUID) (*domain.UserRead, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetByID")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersRead) {
 		return nil, shared.ErrForbidden
 	}
 
-	foundUser, err := s.userRepository.GetByID(userID)
+	foundUser, err := s.userRepository.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
@@ -86,18 +158,22 @@ func (s *userService) GetByID(actor *domain.User, userID uuid.UUID) (*domain.Use
 	return &userRead, nil
 }
 
-func (s *userService) Create(actor *domain.User, request domain.UserCreate) (uuid.UUID, error) {
-	if actor == nil || !actor.HasRole(domain.Administrator) {
+func (s *userService) Create(ctx context.Context, actor *domain.User, request domain.UserCreate, client domain.ClientInfo) (uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Create")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
 		return uuid.UUID{}, shared.ErrForbidden
 	}
 
-	// Ensure the unique username constraint in the database is not violated
-	existing, err := s.userRepository.GetByUsername(request.Username)
+	// Ensure the unique username constraint in the database is not violated.
+	// Soft deleted users keep their usernames until they are purged.
+	exists, err := s.userRepository.UsernameExists(ctx, request.Username)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
 
-	if existing != nil {
+	if exists {
 		return uuid.UUID{}, shared.ErrUsernameTaken
 	}
 
@@ -108,7 +184,7 @@ func (s *userService) Create(actor *domain.User, request domain.UserCreate) (uui
 
 	roles := make([]domain.Role, 1)
 
-	role, err := s.roleRepository.GetByName(domain.BasicUser)
+	role, err := s.roleRepository.GetByName(ctx, domain.BasicUser)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
@@ -120,52 +196,573 @@ func (s *userService) Create(actor *domain.User, request domain.UserCreate) (uui
 		return uuid.UUID{}, err
 	}
 
-	err = s.userRepository.Create(newUser)
+	err = s.userRepository.Create(ctx, newUser)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
 
+	s.recordAudit(ctx, domain.AuditUserCreated, actor, client, newUser.ID, map[string]any{
+		"username": request.Username,
+		"email":    request.Email,
+	})
+
 	return newUser.ID, nil
 }
 
-func (s *userService) Update(actor *domain.User, userID uuid.UUID, request domain.UserUpdate) error {
-	if actor == nil || !actor.HasRole(domain.Administrator) {
+func (s *userService) Update(ctx context.Context, actor *domain.User, userID uuid.UUID, request domain.UserUpdate, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Update")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
 		return shared.ErrForbidden
 	}
 
-	user, err := s.userRepository.GetByID(userID)
+	user, err := s.userRepository.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get by ID: %w", err)
 	}
 
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	if err := s.checkUsernameAvailable(ctx, user, request.Username); err != nil {
+		return err
+	}
+
 	user.FirstName = request.FirstName
 	user.LastName = request.LastName
 	user.Email = request.Email
 	user.Username = request.Username
 	user.UpdatedAt = time.Now().UTC()
 
-	err = s.userRepository.UpdateBasic(user)
+	err = s.userRepository.UpdateBasic(ctx, user)
 	if err != nil {
 		return fmt.Errorf("failed to save updated user: %w", err)
 	}
 
+	s.recordAudit(ctx, domain.AuditUserUpdated, actor, client, user.ID, map[string]any{
+		"username":  request.Username,
+		"email":     request.Email,
+		"firstName": request.FirstName,
+		"lastName":  request.LastName,
+	})
+
+	return nil
+}
+
+func (s *userService) Delete(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	if actor.ID == userID {
+		return fmt.Errorf("%w: you can't delete your own account", shared.ErrBadRequest)
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkAdministratorRemoval(actor, user); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	user.DeletedAt = &now
+	user.UpdatedAt = now
+
+	err = s.userRepository.SoftDelete(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = s.sessionRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end user sessions: %w", err)
+	}
+
+	s.recordAudit(ctx, domain.AuditUserDeleted, actor, client, user.ID, nil)
+
+	return nil
+}
+
+func (s *userService) Restore(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Restore")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetDeletedByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get deleted user by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	user.DeletedAt = nil
+	user.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.Restore(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserRestored, actor, client, user.ID, nil)
+
+	return nil
+}
+
+func (s *userService) Purge(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Purge")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetDeletedByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get deleted user by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	err = s.userRepository.Delete(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserPurged, actor, client, user.ID, nil)
+
+	return nil
+}
+
+func (s *userService) ResetPassword(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) (*domain.PasswordResetResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return nil, shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return nil, err
+	}
+
+	temporaryPassword, err := crypto.GenerateTemporaryPassword(temporaryPasswordLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate temporary password: %w", err)
+	}
+
+	pwHash, err := s.passwordHasher.HashPassword(temporaryPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = pwHash
+	user.MustChangePassword = true
+	user.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdatePassword(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save temporary password: %w", err)
+	}
+
+	err = s.sessionRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to end user sessions: %w", err)
+	}
+
+	err = s.accessTokenRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke user access tokens: %w", err)
+	}
+
+	s.recordAudit(ctx, domain.AuditUserPasswordSet, actor, client, user.ID, nil)
+
+	return &domain.PasswordResetResponse{TemporaryPassword: temporaryPassword}, nil
+}
+
+func (s *userService) Unlock(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Unlock")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAttempt = nil
+	user.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.UpdateBasic(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserUnlocked, actor, client, user.ID, nil)
+
+	return nil
+}
+
+func (s *userService) Disable(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Disable")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	if actor.ID == userID {
+		return fmt.Errorf("%w: you can't disable your own account", shared.ErrBadRequest)
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	user.IsDisabled = true
+	user.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.Disable(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = s.sessionRepository.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end user sessions: %w", err)
+	}
+
+	s.recordAudit(ctx, domain.AuditUserDisabled, actor, client, user.ID, nil)
+
+	return nil
+}
+
+func (s *userService) Enable(ctx context.Context, actor *domain.User, userID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.Enable")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	user.IsDisabled = false
+	user.UpdatedAt = time.Now().UTC()
+
+	err = s.userRepository.Enable(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserEnabled, actor, client, user.ID, nil)
+
+	return nil
+}
+
+func (s *userService) SetRoles(ctx context.Context, actor *domain.User, userID uuid.UUID, request domain.UserRolesUpdate, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.SetRoles")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	roleIDs := make([]uuid.UUID, 0, len(request.RoleIDs))
+	grantsAdministrator := false
+
+	for _, roleID := range request.RoleIDs {
+		if slices.Contains(roleIDs, roleID) {
+			continue
+		}
+
+		role, err := s.roleRepository.GetByID(ctx, roleID)
+		if errors.Is(err, shared.ErrNotFound) {
+			return fmt.Errorf("%w: role %s does not exist", shared.ErrBadRequest, roleID)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !user.HasRole(role.Name) {
+			if err := checkCanGrant(actor, role); err != nil {
+				return err
+			}
+		}
+
+		grantsAdministrator = grantsAdministrator || role.Name == domain.Administrator
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	if !grantsAdministrator {
+		if err := checkAdministratorRemoval(actor, user); err != nil {
+			return err
+		}
+	}
+
+	err = s.userRepository.SetRoles(ctx, user.ID, roleIDs)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserRolesSet, actor, client, user.ID, map[string]any{"roleIds": roleIDs})
+
+	return nil
+}
+
+func (s *userService) AddRole(ctx context.Context, actor *domain.User, userID uuid.UUID, roleID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.AddRole")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
+		return shared.ErrForbidden
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get by ID: %w", err)
+	}
+
+	role, err := s.roleRepository.GetByID(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role by ID: %w", err)
+	}
+
+	if err := checkCanGrant(actor, role); err != nil {
+		return err
+	}
+
+	err = s.userRepository.AddRole(ctx, user.ID, role.ID)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserRoleAdded, actor, client, user.ID, map[string]any{"roleId": role.ID})
+
 	return nil
 }
 
-func (s *userService) Delete(actor *domain.User, userID uuid.UUID) error {
-	if actor == nil || !actor.HasRole(domain.Administrator) {
+func (s *userService) RemoveRole(ctx context.Context, actor *domain.User, userID uuid.UUID, roleID uuid.UUID, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.RemoveRole")
+	defer span.End()
+
+	if actor == nil || !actor.Can(domain.PermissionUsersWrite) {
 		return shared.ErrForbidden
 	}
 
-	user, err := s.userRepository.GetByID(userID)
+	user, err := s.userRepository.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get by ID: %w", err)
 	}
 
-	err = s.userRepository.Delete(user)
+	role, err := s.roleRepository.GetByID(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role by ID: %w", err)
+	}
+
+	if role.Name == domain.Administrator {
+		if err := checkAdministratorRemoval(actor, user); err != nil {
+			return err
+		}
+	}
+
+	err = s.userRepository.RemoveRole(ctx, user.ID, role.ID)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, domain.AuditUserRoleRemoved, actor, client, user.ID, map[string]any{"roleId": role.ID})
+
+	return nil
+}
+
+func (s *userService) GetProfile(ctx context.Context, actor *domain.User) (*domain.UserRead, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetProfile")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	profile := domain.NewUserRead(actor)
+	return &profile, nil
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, actor *domain.User, request domain.ProfileUpdate, client domain.ClientInfo) error {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+
+	if actor == nil {
+		return shared.ErrUnauthorized
+	}
+
+	if !strings.EqualFold(actor.Email, request.Email) {
+		if request.CurrentPassword == "" {
+			return fmt.Errorf("%w: the current password is required to change the email address", shared.ErrBadRequest)
+		}
+
+		match, err := s.passwordHasher.Verify(request.CurrentPassword, actor.PasswordHash)
+		if err != nil {
+			return fmt.Errorf("password verification failed: %w", err)
+		}
+
+		if !match {
+			return fmt.Errorf("%w: the current password is incorrect", shared.ErrBadRequest)
+		}
+	}
+
+	if err := s.checkUsernameAvailable(ctx, actor, request.Username); err != nil {
+		return err
+	}
+
+	actor.FirstName = request.FirstName
+	actor.LastName = request.LastName
+	actor.Email = request.Email
+	actor.Username = request.Username
+	actor.UpdatedAt = time.Now().UTC()
+
+	err := s.userRepository.UpdateBasic(ctx, actor)
+	if err != nil {
+		return fmt.Errorf("failed to save updated profile: %w", err)
+	}
+
+	s.recordAudit(ctx, domain.AuditProfileUpdated, actor, client, actor.ID, map[string]any{
+		"username":  request.Username,
+		"email":     request.Email,
+		"firstName": request.FirstName,
+		"lastName":  request.LastName,
+	})
+
+	return nil
+}
+
+// recordAudit adds a change the actor made to a user to the audit log.
+// The details may be nil.
+func (s *userService) recordAudit(
+	ctx context.Context,
+	action string,
+	actor *domain.User,
+	client domain.ClientInfo,
+	userID uuid.UUID,
+	details map[string]any,
+) {
+	event := domain.NewAuditEvent(action, actor, client).WithTarget(domain.AuditTargetUser, userID.String())
+	if details != nil {
+		event.WithDetails(details)
+	}
+
+	s.auditService.Record(ctx, event)
+}
+
+// checkUsernameAvailable returns ErrUsernameTaken when the user is being
+// given a username that another user already has.
+func (s *userService) checkUsernameAvailable(ctx context.Context, user *domain.User, username string) error {
+	if strings.EqualFold(user.Username, username) {
+		return nil
+	}
+
+	exists, err := s.userRepository.UsernameExists(ctx, username)
 	if err != nil {
 		return err
 	}
 
+	if exists {
+		return shared.ErrUsernameTaken
+	}
+
+	return nil
+}
+
+// checkAdministratorRemoval returns an error when taking the Administrator
+// role away from the user, or deleting them, would demote the actor or
+// isn't allowed for the actor. The repository refuses to remove the last
+// Administrator in the same transaction as the change.
+func checkAdministratorRemoval(actor *domain.User, user *domain.User) error {
+	if !user.HasRole(domain.Administrator) {
+		return nil
+	}
+
+	if err := checkCanManage(actor, user); err != nil {
+		return err
+	}
+
+	if actor.ID == user.ID {
+		return fmt.Errorf("%w: you can't remove your own Administrator role", shared.ErrBadRequest)
+	}
+
+	return nil
+}
+
+// checkCanGrant returns ErrForbidden unless the actor is an Administrator or
+// already holds every permission the role grants, so users:write alone
+// can't be used to gain more permissions.
+func checkCanGrant(actor *domain.User, role *domain.Role) error {
+	if actor.HasRole(domain.Administrator) {
+		return nil
+	}
+
+	for _, permission := range role.Permissions {
+		if !actor.Can(permission) {
+			return fmt.Errorf("%w: the %s role grants %s, which you don't have", shared.ErrForbidden, role.Name, permission)
+		}
+	}
+
+	return nil
+}
+
+// checkCanManage returns ErrForbidden when the user is an Administrator and
+// the actor couldn't grant that role themselves.
+func checkCanManage(actor *domain.User, user *domain.User) error {
+	for i := range user.Roles {
+		if user.Roles[i].Name == domain.Administrator {
+			return checkCanGrant(actor, &user.Roles[i])
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/th3oth3rjak3/mainframe/internal/domain"
+	"github.com/th3oth3rjak3/mainframe/internal/repository"
+	"github.com/th3oth3rjak3/mainframe/internal/shared"
+)
+
+// webAuthnCeremonyTimeout is how long a user has to complete a ceremony.
+const webAuthnCeremonyTimeout = 5 * time.Minute
+
+// WebAuthnConfig describes the relying party that passkeys are bound to.
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+}
+
+type WebAuthnService interface {
+	// BeginRegistration starts registering a new authenticator for the actor.
+	BeginRegistration(ctx context.Context, actor *domain.User) (*domain.WebAuthnBeginResponse, error)
+
+	// FinishRegistration verifies the authenticator's response and saves the credential.
+	FinishRegistration(ctx context.Context, actor *domain.User, request domain.WebAuthnRegistrationFinishRequest) (*domain.WebAuthnCredentialRead, error)
+
+	// BeginLogin starts a discoverable passkey login. No username is needed.
+	BeginLogin(ctx context.Context) (*domain.WebAuthnBeginResponse, error)
+
+	// FinishLogin verifies the assertion and returns the user it belongs to.
+	// It does not create a session.
+	FinishLogin(ctx context.Context, request domain.WebAuthnLoginFinishRequest) (*domain.User, error)
+
+	// ListCredentials returns the authenticators registered to the actor.
+	ListCredentials(ctx context.Context, actor *domain.User) ([]domain.WebAuthnCredentialRead, error)
+
+	// RenameCredential changes the name of one of the actor's authenticators.
+	RenameCredential(ctx context.Context, actor *domain.User, credentialID uuid.UUID, request domain.WebAuthnCredentialRename) error
+
+	// DeleteCredential revokes one of the actor's authenticators.
+	DeleteCredential(ctx context.Context, actor *domain.User, credentialID uuid.UUID) error
+}
+
+func NewWebAuthnService(
+	userRepository repository.UserRepository,
+	webAuthnRepository repository.WebAuthnRepository,
+	config WebAuthnConfig,
+) (WebAuthnService, error) {
+	relyingParty, err := webauthn.New(&webauthn.Config{
+		RPID:          config.RPID,
+		RPDisplayName: config.RPDisplayName,
+		RPOrigins:     config.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure webauthn: %w", err)
+	}
+
+	return &webAuthnService{
+		userRepository:     userRepository,
+		webAuthnRepository: webAuthnRepository,
+		relyingParty:       relyingParty,
+	}, nil
+}
+
+type webAuthnService struct {
+	userRepository     repository.UserRepository
+	webAuthnRepository repository.WebAuthnRepository
+	relyingParty       *webauthn.WebAuthn
+}
+
+// webAuthnUser adapts a domain user and their stored credentials to the
+// webauthn.User interface.
+type webAuthnUser struct {
+	user        *domain.User
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return fmt.Sprintf("%s %s", u.user.FirstName, u.user.LastName)
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (s *webAuthnService) BeginRegistration(ctx context.Context, actor *domain.User) (*domain.WebAuthnBeginResponse, error) {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.BeginRegistration")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	user, _, err := s.loadWebAuthnUser(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := webauthn.Credentials(user.credentials).CredentialDescriptors()
+	creation, sessionData, err := s.relyingParty.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin webauthn registration: %w", err)
+	}
+
+	ceremony, err := s.saveCeremony(ctx, &actor.ID, sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.WebAuthnBeginResponse{CeremonyID: ceremony.ID, Options: creation}, nil
+}
+
+func (s *webAuthnService) FinishRegistration(
+	ctx context.Context,
+	actor *domain.User,
+	request domain.WebAuthnRegistrationFinishRequest,
+) (*domain.WebAuthnCredentialRead, error) {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.FinishRegistration")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	sessionData, err := s.consumeCeremony(ctx, request.CeremonyID, &actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(request.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the credential is malformed or invalid", shared.ErrBadRequest)
+	}
+
+	user, _, err := s.loadWebAuthnUser(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.relyingParty.CreateCredential(user, *sessionData, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the credential could not be verified", shared.ErrBadRequest)
+	}
+
+	record, err := json.Marshal(credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize webauthn credential: %w", err)
+	}
+
+	newCredential, err := domain.NewWebAuthnCredential(
+		actor.ID,
+		encodeCredentialID(credential.ID),
+		request.Name,
+		string(record),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.webAuthnRepository.CreateCredential(ctx, newCredential)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialRead := domain.NewWebAuthnCredentialRead(newCredential)
+	return &credentialRead, nil
+}
+
+func (s *webAuthnService) BeginLogin(ctx context.Context) (*domain.WebAuthnBeginResponse, error) {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.BeginLogin")
+	defer span.End()
+
+	assertion, sessionData, err := s.relyingParty.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin webauthn login: %w", err)
+	}
+
+	ceremony, err := s.saveCeremony(ctx, nil, sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.WebAuthnBeginResponse{CeremonyID: ceremony.ID, Options: assertion}, nil
+}
+
+func (s *webAuthnService) FinishLogin(ctx context.Context, request domain.WebAuthnLoginFinishRequest) (*domain.User, error) {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.FinishLogin")
+	defer span.End()
+
+	sessionData, err := s.consumeCeremony(ctx, request.CeremonyID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(request.Credential)
+	if err != nil {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	var stored []domain.WebAuthnCredential
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := s.userRepository.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		webUser, credentials, err := s.loadWebAuthnUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		stored = credentials
+		return webUser, nil
+	}
+
+	validatedUser, credential, err := s.relyingParty.ValidatePasskeyLogin(findUser, *sessionData, parsed)
+	if err != nil {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return nil, shared.ErrInvalidCredentials
+	}
+
+	user := validatedUser.(*webAuthnUser).user
+	credentialID := encodeCredentialID(credential.ID)
+
+	for _, storedCredential := range stored {
+		if storedCredential.CredentialID != credentialID {
+			continue
+		}
+
+		record, err := json.Marshal(credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize webauthn credential: %w", err)
+		}
+
+		now := time.Now().UTC()
+		storedCredential.Credential = string(record)
+		storedCredential.LastUsedAt = &now
+
+		err = s.webAuthnRepository.UpdateCredential(ctx, &storedCredential)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+
+	return nil, shared.ErrInvalidCredentials
+}
+
+func (s *webAuthnService) ListCredentials(ctx context.Context, actor *domain.User) ([]domain.WebAuthnCredentialRead, error) {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.ListCredentials")
+	defer span.End()
+
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	credentials, err := s.webAuthnRepository.GetCredentialsByUserID(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialList := make([]domain.WebAuthnCredentialRead, len(credentials))
+	for idx, credential := range credentials {
+		credentialList[idx] = domain.NewWebAuthnCredentialRead(&credential)
+	}
+
+	return credentialList, nil
+}
+
+func (s *webAuthnService) RenameCredential(
+	ctx context.Context,
+	actor *domain.User,
+	credentialID uuid.UUID,
+	request domain.WebAuthnCredentialRename,
+) error {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.RenameCredential")
+	defer span.End()
+
+	credential, err := s.getOwnedCredential(ctx, actor, credentialID)
+	if err != nil {
+		return err
+	}
+
+	credential.Name = request.Name
+
+	return s.webAuthnRepository.UpdateCredential(ctx, credential)
+}
+
+func (s *webAuthnService) DeleteCredential(ctx context.Context, actor *domain.User, credentialID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "WebAuthnService.DeleteCredential")
+	defer span.End()
+
+	credential, err := s.getOwnedCredential(ctx, actor, credentialID)
+	if err != nil {
+		return err
+	}
+
+	return s.webAuthnRepository.DeleteCredential(ctx, credential)
+}
+
+// getOwnedCredential fetches a credential and makes sure it belongs to the
+// actor. Credentials owned by someone else are reported as not found.
+func (s *webAuthnService) getOwnedCredential(ctx context.Context, actor *domain.User, credentialID uuid.UUID) (*domain.WebAuthnCredential, error) {
+	if actor == nil {
+		return nil, shared.ErrUnauthorized
+	}
+
+	credential, err := s.webAuthnRepository.GetCredentialByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.UserID != actor.ID {
+		return nil, shared.ErrNotFound
+	}
+
+	return credential, nil
+}
+
+// loadWebAuthnUser fetches the stored credentials for the user and returns
+// them both as a webauthn.User and as the raw stored records.
+func (s *webAuthnService) loadWebAuthnUser(ctx context.Context, user *domain.User) (*webAuthnUser, []domain.WebAuthnCredential, error) {
+	stored, err := s.webAuthnRepository.GetCredentialsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credentials := make([]webauthn.Credential, len(stored))
+	for idx, storedCredential := range stored {
+		err := json.Unmarshal([]byte(storedCredential.Credential), &credentials[idx])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to deserialize webauthn credential: %w", err)
+		}
+	}
+
+	return &webAuthnUser{user: user, credentials: credentials}, stored, nil
+}
+
+// saveCeremony persists the ceremony state so the finish request can be
+// handled by any server instance.
+func (s *webAuthnService) saveCeremony(ctx context.Context, userID *uuid.UUID, sessionData *webauthn.SessionData) (*domain.WebAuthnCeremony, error) {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize webauthn session: %w", err)
+	}
+
+	ceremony, err := domain.NewWebAuthnCeremony(userID, string(data), time.Now().UTC().Add(webAuthnCeremonyTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.webAuthnRepository.CreateCeremony(ctx, ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	return ceremony, nil
+}
+
+// consumeCeremony loads and deletes a ceremony so it can only be used once.
+// When userID is provided, the ceremony must have been started by that user.
+func (s *webAuthnService) consumeCeremony(ctx context.Context, ceremonyIDString string, userID *uuid.UUID) (*webauthn.SessionData, error) {
+	ceremonyID, err := uuid.Parse(ceremonyIDString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the ceremony id was malformed or invalid", shared.ErrBadRequest)
+	}
+
+	ceremony, err := s.webAuthnRepository.GetCeremonyByID(ctx, ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ceremony == nil || ceremony.ExpiresAt.Before(time.Now().UTC()) {
+		return nil, fmt.Errorf("%w: the ceremony has expired or does not exist", shared.ErrBadRequest)
+	}
+
+	if userID != nil && (ceremony.UserID == nil || *ceremony.UserID != *userID) {
+		return nil, fmt.Errorf("%w: the ceremony has expired or does not exist", shared.ErrBadRequest)
+	}
+
+	err = s.webAuthnRepository.DeleteCeremonyByID(ctx, ceremony.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionData webauthn.SessionData
+	err = json.Unmarshal([]byte(ceremony.SessionData), &sessionData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize webauthn session: %w", err)
+	}
+
+	return &sessionData, nil
+}
+
+func encodeCredentialID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
@@ -3,6 +3,7 @@ package shared
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/gofiber/fiber/v2"
@@ -19,8 +20,26 @@ var (
 	ErrUnauthorized       = errors.New("unauthorized")
 	ErrForbidden          = errors.New("forbidden")
 	ErrUsernameTaken      = errors.New("username already exists")
+	ErrRoleNameTaken      = errors.New("role name already exists")
+	ErrRoleInUse          = errors.New("role is assigned to users")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrTooManyRequests    = errors.New("too many requests")
 )
 
+// RateLimitError is returned when a request is over a rate limit.
+// RetryAfter is how long the client should wait before trying again.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return ErrTooManyRequests.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrTooManyRequests
+}
+
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
@@ -57,7 +76,7 @@ func ResolveError(err error) (int, string) {
 	switch {
 	case errors.Is(err, ErrNotFound):
 		return http.StatusNotFound, err.Error()
-	case errors.Is(err, ErrUsernameTaken):
+	case errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrRoleNameTaken), errors.Is(err, ErrRoleInUse):
 		return http.StatusConflict, err.Error()
 	case errors.Is(err, ErrForbidden):
 		return http.StatusForbidden, err.Error()